@@ -5,14 +5,18 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/df-mc/dragonfly/server"
 	"github.com/df-mc/dragonfly/server/player/chat"
 	"github.com/pelletier/go-toml"
 	"github.com/skuralll/df-permission/permission"
 	"github.com/skuralll/dfeconomy/dragonfly/commands"
+	"github.com/skuralll/dfeconomy/economy"
 	"github.com/skuralll/dfeconomy/economy/config"
 	"github.com/skuralll/dfeconomy/economy/service"
+	"github.com/skuralll/dfeconomy/httpapi"
 )
 
 func main() {
@@ -26,11 +30,11 @@ func main() {
 	srv := conf.New()
 	srv.CloseOnProgramEnd()
 
-	cfg := config.Config{
-		DBType:         "sqlite",
-		DBDSN:          "./economy.db",
-		DefaultBalance: 100.0,
-		EnableSetCmd:   false, // Disable set command by default for security
+	// Created with defaults on first run, the set command is disabled by default for security
+	cfg, err := config.Load("economy.toml")
+	if err != nil {
+		slog.Error("Failed to load economy config", "error", err)
+		os.Exit(1)
 	}
 
 	pMgr := permission.NewManager()
@@ -46,13 +50,44 @@ func main() {
 		os.Exit(1)
 	}
 	defer cleanup()
-	commands.RegisterCommands(svc, cfg)
+	commands.RegisterCommands(svc)
+	// Other plugins look the economy up with economy.Provider
+	economy.SetProvider(svc)
+
+	// /economy reload and edits of the file apply the config without a restart
+	svc.SetConfigLoader(func() (config.Config, error) { return config.Load("economy.toml") })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go config.Watch(ctx, "economy.toml", 5*time.Second, func(cfg config.Config, err error) {
+		if err == nil {
+			err = svc.Reload(ctx, cfg)
+		}
+		if err != nil {
+			slog.Error("Failed to reload economy config", "error", err)
+		}
+	})
+
+	// The REST API serves websites and bots while the server runs
+	var api sync.WaitGroup
+	if cfg.HTTP.Enabled {
+		api.Add(1)
+		go func() {
+			defer api.Done()
+			if err := httpapi.New(svc).ListenAndServe(ctx, cfg.HTTP.Addr); err != nil {
+				slog.Error("REST API failed", "error", err)
+			}
+		}()
+	}
 
 	srv.Listen()
 	for p := range srv.Accept() {
 		_ = p
 		svc.RegisterUser(context.Background(), p.UUID(), p.Name())
 	}
+
+	// Let API requests in progress finish before closing the database
+	cancel()
+	api.Wait()
 }
 
 // readConfig reads the configuration from the config.toml file, or creates the
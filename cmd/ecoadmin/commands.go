@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+var errUsage = errors.New("invalid usage")
+
+// admin runs ecoadmin commands against the economy service.
+type admin struct {
+	svc   *service.EconomyService
+	stdin io.Reader
+	out   *printer
+}
+
+type balanceResult struct {
+	Player   string `json:"player"`
+	UUID     string `json:"uuid"`
+	Currency string `json:"currency"`
+	Balance  string `json:"balance"`
+}
+
+type changeResult struct {
+	Command  string `json:"command"`
+	Player   string `json:"player"`
+	UUID     string `json:"uuid"`
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+	Balance  string `json:"balance"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+type lookupResult struct {
+	Player string `json:"player"`
+	UUID   string `json:"uuid"`
+}
+
+type topResult struct {
+	Rank    int    `json:"rank"`
+	Player  string `json:"player"`
+	UUID    string `json:"uuid"`
+	Balance string `json:"balance"`
+}
+
+type auditResult struct {
+	Account  string `json:"account"`
+	UUID     string `json:"uuid"`
+	Currency string `json:"currency"`
+	Balance  string `json:"balance"`
+	Ledger   string `json:"ledger"`
+}
+
+type verifyResult struct {
+	OK          bool              `json:"ok"`
+	Conserved   bool              `json:"conserved"`
+	NonNegative bool              `json:"non_negative"`
+	Balances    int               `json:"balances"`
+	Supply      map[string]string `json:"supply"`
+	Mismatched  []auditResult     `json:"mismatched"`
+	Negative    []auditResult     `json:"negative"`
+	Overflow    []string          `json:"overflow"`
+}
+
+type bulkResult struct {
+	Line   int           `json:"line"`
+	Input  string        `json:"input"`
+	OK     bool          `json:"ok"`
+	Error  string        `json:"error,omitempty"`
+	Result *changeResult `json:"result,omitempty"`
+}
+
+// exec runs a single command.
+func (a *admin) exec(ctx context.Context, name string, args []string) error {
+	switch name {
+	case "balance":
+		return a.balance(ctx, args)
+	case "set", "give", "take":
+		res, err := a.change(ctx, name, args)
+		if err != nil {
+			return err
+		}
+		a.out.print(res, formatChange(res))
+		return nil
+	case "lookup":
+		return a.lookup(ctx, args)
+	case "top":
+		return a.top(ctx, args)
+	case "bulk":
+		return a.bulk(ctx, args)
+	case "verify":
+		return a.verify(ctx, args)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, name)
+	}
+}
+
+func (a *admin) balance(ctx context.Context, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("%w: balance <player> [currency]", errUsage)
+	}
+	id, err := a.svc.GetUUIDByName(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	currencies := a.svc.Currencies()
+	if len(args) == 2 {
+		c, err := a.svc.Currency(args[1])
+		if err != nil {
+			return err
+		}
+		currencies = []economy.Currency{c}
+	}
+	results := make([]balanceResult, 0, len(currencies))
+	lines := make([]string, 0, len(currencies))
+	for _, c := range currencies {
+		amount, err := a.svc.GetBalance(ctx, id, c.Name)
+		if err != nil {
+			return err
+		}
+		results = append(results, balanceResult{args[0], id.String(), c.Name, amount.Format(c.Decimals)})
+		lines = append(lines, fmt.Sprintf("%s: %s", args[0], c.Format(amount)))
+	}
+	a.out.print(results, strings.Join(lines, "\n"))
+	return nil
+}
+
+// change runs set, give or take and returns the result.
+func (a *admin) change(ctx context.Context, name string, args []string) (changeResult, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	reason := flags.String("reason", "", "audit reason")
+	force := flags.Bool("force", false, "allow a negative balance")
+	key := flags.String("key", "", "idempotency key, a repeated key is not applied again")
+	if err := flags.Parse(args); err != nil {
+		return changeResult{}, fmt.Errorf("%w: %s: %v", errUsage, name, err)
+	}
+	args = flags.Args()
+	if len(args) < 2 || len(args) > 3 {
+		return changeResult{}, fmt.Errorf("%w: %s <player> <amount> [currency]", errUsage, name)
+	}
+	if name == "set" && (*reason != "" || *force) {
+		return changeResult{}, fmt.Errorf("%w: set takes only the -key flag", errUsage)
+	}
+	if name == "give" && *force {
+		return changeResult{}, fmt.Errorf("%w: give takes no -force flag", errUsage)
+	}
+
+	player := args[0]
+	var currency string
+	if len(args) == 3 {
+		currency = args[2]
+	}
+	c, err := a.svc.Currency(currency)
+	if err != nil {
+		return changeResult{}, err
+	}
+	amount, err := a.svc.ParseAmount(c.Name, args[1])
+	if err != nil {
+		return changeResult{}, err
+	}
+	id, err := a.svc.GetUUIDByName(ctx, player)
+	if err != nil {
+		return changeResult{}, err
+	}
+
+	if *key != "" {
+		ctx = economy.WithIdempotencyKey(ctx, *key)
+	}
+	var entry economy.Transaction
+	var balance economy.Money
+	switch name {
+	case "set":
+		entry, err = a.svc.SetBalance(ctx, id, player, c.Name, amount, uuid.Nil)
+		balance = entry.ToBalance
+	case "give":
+		entry, err = a.svc.GiveBalance(ctx, id, c.Name, amount, uuid.Nil, *reason)
+		balance = entry.ToBalance
+	case "take":
+		entry, err = a.svc.TakeBalance(ctx, id, c.Name, amount, *force, uuid.Nil, *reason)
+		balance = entry.FromBalance
+	}
+	if err != nil {
+		return changeResult{}, err
+	}
+	return changeResult{
+		Command:  name,
+		Player:   player,
+		UUID:     id.String(),
+		Currency: c.Name,
+		Amount:   amount.Format(c.Decimals),
+		Balance:  balance.Format(c.Decimals),
+		Reason:   entry.Reason,
+	}, nil
+}
+
+func (a *admin) lookup(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: lookup <player>", errUsage)
+	}
+	id, err := a.svc.GetUUIDByName(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	a.out.print(lookupResult{args[0], id.String()}, id.String())
+	return nil
+}
+
+func (a *admin) top(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("top", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	page := flags.Int("page", 1, "page to print")
+	size := flags.Int("size", 10, "entries per page")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: top: %v", errUsage, err)
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("%w: top [-page n] [-size n] [currency]", errUsage)
+	}
+	c, err := a.svc.Currency(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	entries, err := a.svc.GetTopBalances(ctx, c.Name, *page, *size)
+	if err != nil {
+		return err
+	}
+	results := make([]topResult, 0, len(entries))
+	lines := make([]string, 0, len(entries))
+	for i, e := range entries {
+		rank := (*page-1)*(*size) + i + 1
+		results = append(results, topResult{rank, e.Name, e.UUID.String(), e.Balance.Format(c.Decimals)})
+		lines = append(lines, fmt.Sprintf("#%d %s: %s", rank, e.Name, c.Format(e.Balance)))
+	}
+	a.out.print(results, strings.Join(lines, "\n"))
+	return nil
+}
+
+// bulk runs set, give and take lines from a file. Every line is applied on its own,
+// failed lines are reported and do not stop the remaining ones.
+func (a *admin) bulk(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: bulk <file>", errUsage)
+	}
+	r := a.stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var results []bulkResult
+	failed := 0
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		input := strings.TrimSpace(scanner.Text())
+		if input == "" || strings.HasPrefix(input, "#") {
+			continue
+		}
+		res := bulkResult{Line: line, Input: input}
+		fields, err := splitArgs(input)
+		if err == nil && (len(fields) == 0 || fields[0] != "set" && fields[0] != "give" && fields[0] != "take") {
+			err = fmt.Errorf("%w: only set, give and take are allowed", errUsage)
+		}
+		if err == nil {
+			var change changeResult
+			if change, err = a.change(ctx, fields[0], fields[1:]); err == nil {
+				res.Result = &change
+			}
+		}
+		if err != nil {
+			failed++
+			res.Error = err.Error()
+			if !a.out.json {
+				fmt.Fprintf(a.out.w, "line %d: error: %v\n", line, err)
+			}
+		} else {
+			res.OK = true
+			if !a.out.json {
+				fmt.Fprintf(a.out.w, "line %d: %s\n", line, formatChange(*res.Result))
+			}
+		}
+		results = append(results, res)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if a.out.json {
+		a.out.print(results, "")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d operations failed", failed, len(results))
+	}
+	return nil
+}
+
+// verify checks every balance against the ledger and fails if any differs or is negative.
+func (a *admin) verify(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: verify takes no arguments", errUsage)
+	}
+	report, err := a.svc.VerifyInvariants(ctx)
+	if err != nil {
+		return err
+	}
+	result := verifyResult{
+		OK:          report.OK(),
+		Conserved:   report.Conserved(),
+		NonNegative: report.NonNegative(),
+		Balances:    report.Balances,
+		Supply:      make(map[string]string, len(report.Supply)),
+		Mismatched:  a.auditResults(report.Mismatched),
+		Negative:    a.auditResults(report.Negative),
+		Overflow:    append([]string{}, report.Overflow...),
+	}
+	var lines []string
+	for _, r := range result.Mismatched {
+		lines = append(lines, fmt.Sprintf("mismatch %s %s: balance %s, ledger %s", r.Account, r.Currency, r.Balance, r.Ledger))
+	}
+	for _, r := range result.Negative {
+		lines = append(lines, fmt.Sprintf("negative %s %s: balance %s", r.Account, r.Currency, r.Balance))
+	}
+	for _, c := range result.Overflow {
+		lines = append(lines, "overflow "+c)
+	}
+	currencies := make([]string, 0, len(report.Supply))
+	for c := range report.Supply {
+		currencies = append(currencies, c)
+	}
+	slices.Sort(currencies)
+	for _, c := range currencies {
+		result.Supply[c] = a.amount(c, report.Supply[c])
+		lines = append(lines, fmt.Sprintf("supply %s: %s", c, result.Supply[c]))
+	}
+	lines = append(lines, fmt.Sprintf("%d balances checked", report.Balances))
+	a.out.print(result, strings.Join(lines, "\n"))
+	if !result.Conserved {
+		return fmt.Errorf("%d of %d balances do not match the ledger", len(report.Mismatched), report.Balances)
+	}
+	if !result.NonNegative {
+		return fmt.Errorf("%d of %d balances are negative", len(report.Negative), report.Balances)
+	}
+	return nil
+}
+
+// auditResults converts audit entries for printing.
+func (a *admin) auditResults(audit []economy.BalanceAudit) []auditResult {
+	results := make([]auditResult, 0, len(audit))
+	for _, e := range audit {
+		name := e.Name
+		if name == "" {
+			name = e.Account.String()
+		}
+		results = append(results, auditResult{
+			Account:  name,
+			UUID:     e.Account.String(),
+			Currency: e.Currency,
+			Balance:  a.amount(e.Currency, e.Balance),
+			Ledger:   a.amount(e.Currency, e.Ledger),
+		})
+	}
+	return results
+}
+
+// amount formats minor units as a decimal, currencies no longer configured use the default scale.
+func (a *admin) amount(currency string, m economy.Money) string {
+	c, err := a.svc.Currency(currency)
+	if err != nil {
+		return m.Format(economy.DefaultScale)
+	}
+	return m.Format(c.Decimals)
+}
+
+// formatChange describes a balance change in text mode.
+func formatChange(r changeResult) string {
+	s := fmt.Sprintf("%s %s %s %s: balance %s %s", r.Command, r.Player, r.Amount, r.Currency, r.Balance, r.Currency)
+	if r.Reason != "" {
+		s += " (" + r.Reason + ")"
+	}
+	return s
+}
+
+// splitArgs splits a bulk line into arguments, double quotes group words.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inQuotes, inArg := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inQuotes {
+				cur.WriteRune(r)
+			} else if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("%w: unterminated quote", errUsage)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
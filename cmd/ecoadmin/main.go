@@ -0,0 +1,133 @@
+// Command ecoadmin manages the economy database while the game server is down.
+//
+//	ecoadmin [-config economy.toml] [-db-type sqlite] [-dsn ./economy.db] [-json] <command> [args]
+//
+// Run ecoadmin -h for the list of commands.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/skuralll/dfeconomy/economy/config"
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+const usage = `Usage: ecoadmin [flags] <command> [args]
+
+Commands:
+  balance <player> [currency]                        Show the balance of a player
+  set [-key k] <player> <amount> [currency]          Overwrite the balance of a player
+  give [-key k] [-reason r] <player> <amount> [currency]
+                                                     Add money to a player
+  take [-key k] [-reason r] [-force] <player> <amount> [currency]
+                                                     Remove money from a player
+  lookup <player>                                    Print the UUID of a player
+  top [-page n] [-size n] [currency]                 Print the leaderboard
+  bulk <file>                                        Run set, give and take lines from a file, - for stdin
+  verify                                             Check that every balance matches the ledger and none is negative
+
+Flags:
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ecoadmin", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	configPath := flags.String("config", "", "TOML file with the economy configuration of the server, or its [economy] table")
+	dbType := flags.String("db-type", "", "database type: sqlite, mysql or postgres (default from -config, else sqlite)")
+	dsn := flags.String("dsn", "", "database DSN (default from -config, else ./economy.db)")
+	jsonOut := flags.Bool("json", false, "print results as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	cfg, err := loadConfig(*configPath, *dbType, *dsn)
+	if err != nil {
+		fmt.Fprintln(stderr, "ecoadmin:", err)
+		return 1
+	}
+	// Keep the output clean for scripting
+	slog.SetLogLoggerLevel(slog.LevelWarn)
+	svc, cleanup, err := service.NewEconomyService(cfg, nil)
+	if err != nil {
+		fmt.Fprintln(stderr, "ecoadmin: open economy:", err)
+		return 1
+	}
+	defer cleanup()
+
+	a := &admin{svc: svc, stdin: stdin, out: newPrinter(stdout, *jsonOut)}
+	if err := a.exec(context.Background(), flags.Arg(0), flags.Args()[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			flags.Usage()
+			return 2
+		}
+		fmt.Fprintln(stderr, "ecoadmin:", err)
+		return 1
+	}
+	return 0
+}
+
+// loadConfig reads the server configuration, environment variables and flags
+// override the connection settings.
+func loadConfig(path, dbType, dsn string) (config.Config, error) {
+	cfg := config.Default()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("read config: %w", err)
+		}
+		if cfg, err = config.Parse(data); err != nil {
+			return config.Config{}, err
+		}
+	}
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return config.Config{}, err
+	}
+	if dbType != "" {
+		cfg.DBType = dbType
+	}
+	if dsn != "" {
+		cfg.DBDSN = dsn
+	}
+	return cfg, cfg.Validate()
+}
+
+// printer writes results as text or JSON.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func newPrinter(w io.Writer, jsonOut bool) *printer {
+	return &printer{w: w, json: jsonOut}
+}
+
+// print writes v as a JSON document, or text as a line in text mode.
+func (p *printer) print(v any, text string) {
+	if p.json {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(v)
+		return
+	}
+	fmt.Fprintln(p.w, text)
+}
@@ -6,16 +6,20 @@ import (
 	"fmt"
 
 	"github.com/df-mc/dragonfly/server/cmd"
+	"github.com/df-mc/dragonfly/server/player"
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/google/uuid"
+
+	"github.com/skuralll/dfeconomy/economy"
 )
 
-// /economy balance <target>
+// /economy balance <target> [currency]
 
 type EconomyBalanceCommand struct {
 	*BaseCommand
 	SubCmd   cmd.SubCommand       `cmd:"balance" help:"Displays the balance of a player."`
 	Username cmd.Optional[string] `cmd:"username"`
+	Currency cmd.Optional[string] `cmd:"currency"`
 }
 
 func (e *EconomyBalanceCommand) Allow(src cmd.Source) bool {
@@ -23,41 +27,58 @@ func (e *EconomyBalanceCommand) Allow(src cmd.Source) bool {
 }
 
 func (e EconomyBalanceCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
-	p, ok := e.ValidatePlayerSource(src, o)
+	reply := e.Replier(src)
+	// get target name, non-player sources have no balance of their own
+	p, isPlayer := src.(*player.Player)
+	tn, ok := e.Username.Load()
 	if !ok {
-		return
+		if !isPlayer {
+			o.Error("Specify a username")
+			return
+		}
+		tn = p.Name()
 	}
 
 	// Provide immediate feedback
 	o.Printf("Fetching balance...")
 
-	e.ExecuteAsync(p, func(ctx context.Context) {
+	e.ExecuteAsync(func(ctx context.Context) {
 		// get target uuid
-		tn := e.Username.LoadOr(p.Name())
 		var uid uuid.UUID
-		if tn == p.Name() {
+		if isPlayer && tn == p.Name() {
 			uid = p.UUID()
 		} else {
 			// get uuid by name
 			var err error
-			uid, err = e.GetUUIDByName(ctx, p, tn)
+			uid, err = e.GetUUIDByName(ctx, reply, tn)
 			if err != nil {
 				return
 			}
 		}
 
-		// get balance
-		amount, err := e.svc.GetBalance(ctx, uid)
-		if err != nil {
-			if errors.Is(err, context.DeadlineExceeded) {
-				p.Message("§c[Error] Request timeout")
-			} else {
-				p.Message("§c[Error] Failed to get balance")
+		// get balances, all currencies unless one is given
+		currencies := e.svc.Currencies()
+		if c, ok := e.Currency.Load(); ok {
+			currency, err := e.svc.Currency(c)
+			if err != nil {
+				reply("§c[Error] Invalid input: " + err.Error())
+				return
 			}
-			return
+			currencies = []economy.Currency{currency}
+		}
+		for _, c := range currencies {
+			amount, err := e.svc.GetBalance(ctx, uid, c.Name)
+			if err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					reply("§c[Error] Request timeout")
+				} else {
+					reply("§c[Error] Failed to get balance")
+				}
+				return
+			}
+			// send message
+			reply(fmt.Sprintf("§a[Balance] %s: %s", tn, c.Format(amount)))
 		}
-		// send message
-		p.Message(fmt.Sprintf("§a[Balance] %s: %.2f", tn, amount))
 	})
 }
 
@@ -0,0 +1,297 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/df-mc/dragonfly/server/cmd"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+// bankRole is the role parameter of /economy bank invite.
+type bankRole string
+
+func (bankRole) Type() string { return "BankRole" }
+
+func (bankRole) Options(cmd.Source) []string {
+	return []string{string(economy.BankRoleDeposit), string(economy.BankRoleWithdraw), string(economy.BankRoleManage)}
+}
+
+// /economy bank create <name>
+
+type EconomyBankCreateCommand struct {
+	*BaseCommand
+	SubCmd cmd.SubCommand `cmd:"bank" help:"Create a shared bank account."`
+	Create cmd.SubCommand `cmd:"create"`
+	Name   string         `cmd:"name"`
+}
+
+func (e *EconomyBankCreateCommand) Allow(src cmd.Source) bool {
+	return e.CheckPlayerPermission(src, "economy.command.bank")
+}
+
+func (e EconomyBankCreateCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, ok := e.ValidatePlayerSource(src, o)
+	if !ok {
+		return
+	}
+	reply := e.Replier(src)
+
+	// Provide immediate feedback
+	o.Printf("Creating bank...")
+
+	e.ExecuteAsync(func(ctx context.Context) {
+		if _, err := e.svc.CreateBank(ctx, p.UUID(), e.Name); err != nil {
+			e.bankError(p, reply, "create bank", err)
+			return
+		}
+		reply(fmt.Sprintf("§a[Success] Created bank %s", e.Name))
+	})
+}
+
+// /economy bank deposit <name> <amount> [currency]
+
+type EconomyBankDepositCommand struct {
+	*BaseCommand
+	SubCmd   cmd.SubCommand       `cmd:"bank" help:"Deposit money into a bank."`
+	Deposit  cmd.SubCommand       `cmd:"deposit"`
+	Name     string               `cmd:"name"`
+	Amount   string               `cmd:"amount"`
+	Currency cmd.Optional[string] `cmd:"currency"`
+}
+
+func (e *EconomyBankDepositCommand) Allow(src cmd.Source) bool {
+	return e.CheckPlayerPermission(src, "economy.command.bank")
+}
+
+func (e EconomyBankDepositCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, ok := e.ValidatePlayerSource(src, o)
+	if !ok {
+		return
+	}
+	reply := e.Replier(src)
+	// validate amount
+	currency := e.Currency.LoadOr("")
+	amount, err := e.svc.ParseAmount(currency, e.Amount)
+	if err != nil {
+		o.Error("Invalid input: " + err.Error())
+		return
+	}
+
+	// Provide immediate feedback
+	o.Printf("Processing deposit...")
+
+	e.ExecuteAsync(func(ctx context.Context) {
+		result, err := e.svc.DepositBank(ctx, p.UUID(), e.Name, currency, amount)
+		if err != nil {
+			e.bankError(p, reply, "deposit", err)
+			return
+		}
+		reply(fmt.Sprintf("§a[Success] Deposited %s into %s", e.svc.FormatAmount(result.Currency, result.Received), e.Name))
+	})
+}
+
+// /economy bank withdraw <name> <amount> [currency]
+
+type EconomyBankWithdrawCommand struct {
+	*BaseCommand
+	SubCmd   cmd.SubCommand       `cmd:"bank" help:"Withdraw money from a bank."`
+	Withdraw cmd.SubCommand       `cmd:"withdraw"`
+	Name     string               `cmd:"name"`
+	Amount   string               `cmd:"amount"`
+	Currency cmd.Optional[string] `cmd:"currency"`
+}
+
+func (e *EconomyBankWithdrawCommand) Allow(src cmd.Source) bool {
+	return e.CheckPlayerPermission(src, "economy.command.bank")
+}
+
+func (e EconomyBankWithdrawCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, ok := e.ValidatePlayerSource(src, o)
+	if !ok {
+		return
+	}
+	reply := e.Replier(src)
+	// validate amount
+	currency := e.Currency.LoadOr("")
+	amount, err := e.svc.ParseAmount(currency, e.Amount)
+	if err != nil {
+		o.Error("Invalid input: " + err.Error())
+		return
+	}
+
+	// Provide immediate feedback
+	o.Printf("Processing withdrawal...")
+
+	e.ExecuteAsync(func(ctx context.Context) {
+		result, err := e.svc.WithdrawBank(ctx, p.UUID(), e.Name, currency, amount)
+		if err != nil {
+			e.bankError(p, reply, "withdraw", err)
+			return
+		}
+		reply(fmt.Sprintf("§a[Success] Withdrew %s from %s", e.svc.FormatAmount(result.Currency, result.Received), e.Name))
+	})
+}
+
+// /economy bank invite <name> <username> [role]
+
+type EconomyBankInviteCommand struct {
+	*BaseCommand
+	SubCmd   cmd.SubCommand         `cmd:"bank" help:"Add a member to a bank or change their role."`
+	Invite   cmd.SubCommand         `cmd:"invite"`
+	Name     string                 `cmd:"name"`
+	Username string                 `cmd:"username"`
+	Role     cmd.Optional[bankRole] `cmd:"role" help:"deposit (default), withdraw or manage."`
+}
+
+func (e *EconomyBankInviteCommand) Allow(src cmd.Source) bool {
+	return e.CheckPlayerPermission(src, "economy.command.bank")
+}
+
+func (e EconomyBankInviteCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, ok := e.ValidatePlayerSource(src, o)
+	if !ok {
+		return
+	}
+	reply := e.Replier(src)
+	role := economy.BankRole(e.Role.LoadOr(bankRole(economy.BankRoleDeposit)))
+
+	// Provide immediate feedback
+	o.Printf("Processing invitation...")
+
+	e.ExecuteAsync(func(ctx context.Context) {
+		// get member uuid
+		muid, err := e.GetUUIDByName(ctx, reply, e.Username)
+		if err != nil {
+			return
+		}
+		if err := e.svc.SetBankMember(ctx, p.UUID(), e.Name, muid, role); err != nil {
+			e.bankError(p, reply, "invite", err)
+			return
+		}
+		reply(fmt.Sprintf("§a[Success] %s is now a %s member of %s", e.Username, role, e.Name))
+	})
+}
+
+// /economy bank kick <name> <username>
+
+type EconomyBankKickCommand struct {
+	*BaseCommand
+	SubCmd   cmd.SubCommand `cmd:"bank" help:"Remove a member from a bank."`
+	Kick     cmd.SubCommand `cmd:"kick"`
+	Name     string         `cmd:"name"`
+	Username string         `cmd:"username"`
+}
+
+func (e *EconomyBankKickCommand) Allow(src cmd.Source) bool {
+	return e.CheckPlayerPermission(src, "economy.command.bank")
+}
+
+func (e EconomyBankKickCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, ok := e.ValidatePlayerSource(src, o)
+	if !ok {
+		return
+	}
+	reply := e.Replier(src)
+
+	// Provide immediate feedback
+	o.Printf("Processing removal...")
+
+	e.ExecuteAsync(func(ctx context.Context) {
+		// get member uuid
+		muid, err := e.GetUUIDByName(ctx, reply, e.Username)
+		if err != nil {
+			return
+		}
+		if err := e.svc.RemoveBankMember(ctx, p.UUID(), e.Name, muid); err != nil {
+			e.bankError(p, reply, "kick", err)
+			return
+		}
+		reply(fmt.Sprintf("§a[Success] Removed %s from %s", e.Username, e.Name))
+	})
+}
+
+// /economy bank info <name>
+
+type EconomyBankInfoCommand struct {
+	*BaseCommand
+	SubCmd cmd.SubCommand `cmd:"bank" help:"Show the balance and members of a bank."`
+	Info   cmd.SubCommand `cmd:"info"`
+	Name   string         `cmd:"name"`
+}
+
+func (e *EconomyBankInfoCommand) Allow(src cmd.Source) bool {
+	return e.CheckPlayerPermission(src, "economy.command.bank")
+}
+
+func (e EconomyBankInfoCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, ok := e.ValidatePlayerSource(src, o)
+	if !ok {
+		return
+	}
+	reply := e.Replier(src)
+
+	// Provide immediate feedback
+	o.Printf("Fetching bank...")
+
+	e.ExecuteAsync(func(ctx context.Context) {
+		bank, err := e.svc.Bank(ctx, e.Name)
+		if err != nil {
+			e.bankError(p, reply, "get bank", err)
+			return
+		}
+		if bank.Role(p.UUID()) == "" {
+			e.bankError(p, reply, "get bank", service.NewForbiddenError("view bank "+bank.Name))
+			return
+		}
+		reply(fmt.Sprintf("§a[Bank %s]", bank.Name))
+		for _, c := range e.svc.Currencies() {
+			amount, err := e.svc.GetBalance(ctx, bank.UUID, c.Name)
+			if err != nil {
+				e.bankError(p, reply, "get balance", err)
+				return
+			}
+			reply(fmt.Sprintf("§7Balance: §r%s", c.Format(amount)))
+		}
+		for _, m := range bank.Members {
+			reply(fmt.Sprintf("§7%s: §r%s", m.Role, m.Name))
+		}
+	})
+}
+
+// bankError reports a failed bank operation to the player.
+func (b *BaseCommand) bankError(p *player.Player, reply replyFunc, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrCancelled):
+		reply("§c[Error] Bank operation " + err.Error())
+	case errors.Is(err, service.ErrValidation), errors.Is(err, service.ErrForbidden),
+		errors.Is(err, service.ErrUnknownBank), errors.Is(err, service.ErrUnknownPlayer):
+		reply("§c[Error] " + err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		reply("§c[Error] Request timeout")
+	default:
+		reply("§c[Error] Failed to " + action + " by internal error")
+		slog.Error("Failed bank operation", "error", err, "action", action, "player", p.Name())
+	}
+}
+
+// Validation
+var _ cmd.Runnable = (*EconomyBankCreateCommand)(nil)
+var _ cmd.Allower = (*EconomyBankCreateCommand)(nil)
+var _ cmd.Runnable = (*EconomyBankDepositCommand)(nil)
+var _ cmd.Allower = (*EconomyBankDepositCommand)(nil)
+var _ cmd.Runnable = (*EconomyBankWithdrawCommand)(nil)
+var _ cmd.Allower = (*EconomyBankWithdrawCommand)(nil)
+var _ cmd.Runnable = (*EconomyBankInviteCommand)(nil)
+var _ cmd.Allower = (*EconomyBankInviteCommand)(nil)
+var _ cmd.Runnable = (*EconomyBankKickCommand)(nil)
+var _ cmd.Allower = (*EconomyBankKickCommand)(nil)
+var _ cmd.Runnable = (*EconomyBankInfoCommand)(nil)
+var _ cmd.Allower = (*EconomyBankInfoCommand)(nil)
+var _ cmd.Enum = bankRole("")
@@ -19,6 +19,9 @@ type BaseCommand struct {
 	svc *service.EconomyService
 }
 
+// replyFunc sends a message to the source of a command, also after Run returned.
+type replyFunc func(msg string)
+
 // ValidatePlayerSource validates that the source is a player and outputs error if not.
 // This method intentionally combines validation and error output for code brevity.
 func (b *BaseCommand) ValidatePlayerSource(src cmd.Source, o *cmd.Output) (*player.Player, bool) {
@@ -29,38 +32,76 @@ func (b *BaseCommand) ValidatePlayerSource(src cmd.Source, o *cmd.Output) (*play
 	return p, ok
 }
 
+// Replier returns a function sending messages to the source. Players receive chat
+// messages, other sources such as the console receive them as command output.
+func (b *BaseCommand) Replier(src cmd.Source) replyFunc {
+	if p, ok := src.(*player.Player); ok {
+		return func(msg string) { p.Message(msg) }
+	}
+	return func(msg string) {
+		o := &cmd.Output{}
+		o.Print(msg)
+		src.SendCommandOutput(o)
+	}
+}
+
+// SourceActor returns the UUID and name recorded as the actor of an operation
+// started by the source. Non-player sources act as the system with uuid.Nil.
+func (b *BaseCommand) SourceActor(src cmd.Source) (uuid.UUID, string) {
+	if p, ok := src.(*player.Player); ok {
+		return p.UUID(), p.Name()
+	}
+	return uuid.Nil, "console"
+}
+
 // Create Context with Timeout creates a context with a 5-second timeout.
 func (b *BaseCommand) CreateContextWithTimeout() (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), DefaultCommandTimeout)
 }
 
 // GetUUIDByName gets UUID by username with automatic error messaging
-func (b *BaseCommand) GetUUIDByName(ctx context.Context, p *player.Player, username string) (uuid.UUID, error) {
+func (b *BaseCommand) GetUUIDByName(ctx context.Context, reply replyFunc, username string) (uuid.UUID, error) {
 	tuid, err := b.svc.GetUUIDByName(ctx, username)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			p.Message("§c[Error] Request timeout")
+			reply("§c[Error] Request timeout")
 		} else {
-			p.Message("§c[Error] Player not found: " + username)
+			reply("§c[Error] Player not found: " + username)
 		}
 	}
 	return tuid, err
 }
 
 // ExecuteAsync executes a function asynchronously with a context.
-func (b *BaseCommand) ExecuteAsync(p *player.Player, fn func(ctx context.Context)) {
+func (b *BaseCommand) ExecuteAsync(fn func(ctx context.Context)) {
+	b.ExecuteAsyncWithTimeout(DefaultCommandTimeout, fn)
+}
+
+// ExecuteAsyncWithTimeout executes a function asynchronously with a context, for
+// commands that take longer than DefaultCommandTimeout.
+func (b *BaseCommand) ExecuteAsyncWithTimeout(timeout time.Duration, fn func(ctx context.Context)) {
 	go func() {
-		ctx, cancel := b.CreateContextWithTimeout()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
 		fn(ctx)
 	}()
 }
 
-// CheckPermission checks if the source has the specified permission.
+// CheckPermission checks if the source has the specified permission. Non-player
+// sources are checked against the console policy of the configuration.
 func (b *BaseCommand) CheckPermission(src cmd.Source, permission string) bool {
 	p, ok := src.(*player.Player)
 	if !ok {
-		return false
+		return b.svc.Config().Console.Allows(permission)
 	}
 	return b.svc.Permission.HasPermission(p.UUID(), permission)
 }
+
+// CheckPlayerPermission checks if the source is a player with the specified permission,
+// for commands that act on the balance of the source itself.
+func (b *BaseCommand) CheckPlayerPermission(src cmd.Source, permission string) bool {
+	if _, ok := src.(*player.Player); !ok {
+		return false
+	}
+	return b.CheckPermission(src, permission)
+}
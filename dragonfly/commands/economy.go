@@ -17,10 +17,26 @@ func (c *EconomyCommand) Allow(src cmd.Source) bool {
 
 func (c EconomyCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
 	o.Printf("§6=== Economy Commands ===")
-	o.Printf("§a/economy balance [username]§r - Display balance of yourself or another player")
-	o.Printf("§a/economy pay <username> <amount>§r - Pay money to another player")
-	o.Printf("§a/economy set <username> <amount>§r - Set a player's balance (Admin)")
-	o.Printf("§a/economy top <page>§r - Show top players by balance")
+	o.Printf("§a/economy balance [username] [currency]§r - Display balance of yourself or another player")
+	o.Printf("§a/economy pay <username> <amount> [currency]§r - Pay money to another player")
+	o.Printf("§a/economy exchange <from> <to> <amount>§r - Convert money between currencies")
+	o.Printf("§a/economy bank create <name>§r - Create a shared bank account")
+	o.Printf("§a/economy bank deposit|withdraw <name> <amount> [currency]§r - Move money into or out of a bank")
+	o.Printf("§a/economy bank invite <name> <username> [role]§r - Add a bank member or change their role")
+	o.Printf("§a/economy bank kick <name> <username>§r - Remove a bank member")
+	o.Printf("§a/economy bank info <name>§r - Show the balance and members of a bank")
+	o.Printf("§a/economy give <username> <amount> [currency] [reason]§r - Add money to a player's balance (Admin)")
+	o.Printf("§a/economy take [force] <username> <amount> [currency] [reason]§r - Remove money from a player's balance (Admin)")
+	if c.svc.Config().EnableSetCmd {
+		o.Printf("§a/economy set <username> <amount> [currency]§r - Set a player's balance (Admin)")
+	}
+	o.Printf("§a/economy top <page> [currency]§r - Show top players by balance")
+	o.Printf("§a/economy history [page] [counterparty]§r - Show your recent transactions")
+	o.Printf("§a/economy history of <username> [page] [counterparty]§r - Show a player's transactions (Admin)")
+	o.Printf("§a/economy treasury [account]§r - Show the balances of system accounts (Admin)")
+	o.Printf("§a/economy treasury transfer <from> <to> <amount> [currency]§r - Move money from or to a system account (Admin)")
+	o.Printf("§a/economy reload§r - Reload the economy config (Admin)")
+	o.Printf("§a/economy verify§r - Check that every balance matches the ledger (Admin)")
 }
 
 // Validation
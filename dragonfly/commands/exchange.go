@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/df-mc/dragonfly/server/cmd"
+	"github.com/df-mc/dragonfly/server/world"
+
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+// /economy exchange <from> <to> <amount>
+
+type EconomyExchangeCommand struct {
+	*BaseCommand
+	SubCmd cmd.SubCommand `cmd:"exchange" help:"Convert money between currencies."`
+	From   string         `cmd:"from"`
+	To     string         `cmd:"to"`
+	Amount string         `cmd:"amount"`
+}
+
+func (e *EconomyExchangeCommand) Allow(src cmd.Source) bool {
+	return e.CheckPlayerPermission(src, "economy.command.exchange")
+}
+
+func (e EconomyExchangeCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, ok := e.ValidatePlayerSource(src, o)
+	if !ok {
+		return
+	}
+	reply := e.Replier(src)
+	// validate amount
+	amount, err := e.svc.ParseAmount(e.From, e.Amount)
+	if err != nil {
+		o.Error("Invalid input: " + err.Error())
+		return
+	}
+
+	// Provide immediate feedback
+	o.Printf("Processing exchange...")
+
+	e.ExecuteAsync(func(ctx context.Context) {
+		quote, err := e.svc.Exchange(ctx, p.UUID(), e.From, e.To, amount)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrUnknownPlayer):
+				reply("§c[Error] Player not found: " + p.Name())
+			case errors.Is(err, service.ErrValidation):
+				reply("§c[Error] Invalid input: " + err.Error())
+			case errors.Is(err, context.DeadlineExceeded):
+				reply("§c[Error] Request timeout")
+			default:
+				reply("§c[Error] Failed to exchange by internal error")
+				slog.Error("Failed to exchange", "error", err, "player", p.Name(), "from", e.From, "to", e.To, "amount", e.Amount)
+			}
+			return
+		}
+		// success
+		reply(fmt.Sprintf("§a[Success] Exchanged %s for %s (fee %s)",
+			e.svc.FormatAmount(quote.From, quote.Amount),
+			e.svc.FormatAmount(quote.To, quote.Received),
+			e.svc.FormatAmount(quote.To, quote.Fee)))
+	})
+}
+
+// Validation
+var _ cmd.Runnable = (*EconomyExchangeCommand)(nil)
+var _ cmd.Allower = (*EconomyExchangeCommand)(nil)
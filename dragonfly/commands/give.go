@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/df-mc/dragonfly/server/cmd"
+	"github.com/df-mc/dragonfly/server/world"
+
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+// /economy give <username> <amount> [currency] [reason]
+
+type EconomyGiveCommand struct {
+	*BaseCommand
+	SubCmd   cmd.SubCommand            `cmd:"give" help:"Add money to the balance of a player."`
+	Username string                    `cmd:"username"`
+	Amount   string                    `cmd:"amount"`
+	Currency cmd.Optional[string]      `cmd:"currency"`
+	Reason   cmd.Optional[cmd.Varargs] `cmd:"reason"`
+}
+
+func (e *EconomyGiveCommand) Allow(src cmd.Source) bool {
+	return e.CheckPermission(src, "economy.command.give")
+}
+
+func (e EconomyGiveCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	e.adjust(src, o, e.Username, e.Amount, e.Currency, e.Reason, false, false)
+}
+
+// /economy take <username> <amount> [currency] [reason]
+
+type EconomyTakeCommand struct {
+	*BaseCommand
+	SubCmd   cmd.SubCommand            `cmd:"take" help:"Remove money from the balance of a player."`
+	Username string                    `cmd:"username"`
+	Amount   string                    `cmd:"amount"`
+	Currency cmd.Optional[string]      `cmd:"currency"`
+	Reason   cmd.Optional[cmd.Varargs] `cmd:"reason"`
+}
+
+func (e *EconomyTakeCommand) Allow(src cmd.Source) bool {
+	return e.CheckPermission(src, "economy.command.take")
+}
+
+func (e EconomyTakeCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	e.adjust(src, o, e.Username, e.Amount, e.Currency, e.Reason, true, false)
+}
+
+// /economy take force <username> <amount> [currency] [reason]
+
+type EconomyTakeForceCommand struct {
+	*BaseCommand
+	SubCmd   cmd.SubCommand            `cmd:"take" help:"Remove money from a player even if the balance goes negative."`
+	Force    cmd.SubCommand            `cmd:"force"`
+	Username string                    `cmd:"username"`
+	Amount   string                    `cmd:"amount"`
+	Currency cmd.Optional[string]      `cmd:"currency"`
+	Reason   cmd.Optional[cmd.Varargs] `cmd:"reason"`
+}
+
+func (e *EconomyTakeForceCommand) Allow(src cmd.Source) bool {
+	return e.CheckPermission(src, "economy.command.take.force")
+}
+
+func (e EconomyTakeForceCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	e.adjust(src, o, e.Username, e.Amount, e.Currency, e.Reason, true, true)
+}
+
+// adjust gives or takes money from the balance of a player.
+func (b *BaseCommand) adjust(src cmd.Source, o *cmd.Output, username, rawAmount string, first cmd.Optional[string], rest cmd.Optional[cmd.Varargs], take, force bool) {
+	reply := b.Replier(src)
+	// validate amount
+	currency, reason := b.currencyAndReason(first, rest)
+	amount, err := b.svc.ParseAmount(currency, rawAmount)
+	if err != nil {
+		o.Error("Invalid input: " + err.Error())
+		return
+	}
+	if amount <= 0 {
+		o.Error("Amount must be positive")
+		return
+	}
+
+	// Provide immediate feedback
+	o.Printf("Processing balance update...")
+
+	actor, actorName := b.SourceActor(src)
+	b.ExecuteAsync(func(ctx context.Context) {
+		// get target uuid
+		tuid, err := b.GetUUIDByName(ctx, reply, username)
+		if err != nil {
+			return
+		}
+		// change balance
+		var entry economy.Transaction
+		if take {
+			entry, err = b.svc.TakeBalance(ctx, tuid, currency, amount, force, actor, reason)
+		} else {
+			entry, err = b.svc.GiveBalance(ctx, tuid, currency, amount, actor, reason)
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrValidation):
+				reply("§c[Error] Invalid input: " + err.Error())
+			case errors.Is(err, context.DeadlineExceeded):
+				reply("§c[Error] Request timeout")
+			default:
+				reply("§c[Error] Failed to update balance by internal error")
+				slog.Error("Failed to adjust balance", "error", err, "actor", actorName, "target", username, "amount", b.svc.FormatAmount(currency, amount))
+			}
+			return
+		}
+		// success
+		if take {
+			reply(fmt.Sprintf("§a[Success] Took %s from %s (balance %s)", b.svc.FormatAmount(entry.Currency, entry.Amount), username, b.svc.FormatAmount(entry.Currency, entry.FromBalance)))
+			return
+		}
+		reply(fmt.Sprintf("§a[Success] Gave %s to %s (balance %s)", b.svc.FormatAmount(entry.Currency, entry.Amount), username, b.svc.FormatAmount(entry.Currency, entry.ToBalance)))
+	})
+}
+
+// currencyAndReason splits the optional arguments following the amount. The first
+// word is the currency if it names one, otherwise it starts the reason.
+func (b *BaseCommand) currencyAndReason(first cmd.Optional[string], rest cmd.Optional[cmd.Varargs]) (currency, reason string) {
+	word, ok := first.Load()
+	if !ok {
+		return "", ""
+	}
+	reason = string(rest.LoadOr(""))
+	if _, err := b.svc.Currency(word); err == nil {
+		return word, reason
+	}
+	return "", strings.TrimSpace(word + " " + reason)
+}
+
+// Validation
+var _ cmd.Runnable = (*EconomyGiveCommand)(nil)
+var _ cmd.Allower = (*EconomyGiveCommand)(nil)
+var _ cmd.Runnable = (*EconomyTakeCommand)(nil)
+var _ cmd.Allower = (*EconomyTakeCommand)(nil)
+var _ cmd.Runnable = (*EconomyTakeForceCommand)(nil)
+var _ cmd.Allower = (*EconomyTakeForceCommand)(nil)
@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/df-mc/dragonfly/server/cmd"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/google/uuid"
+
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+// /economy history [page] [counterparty]
+
+type EconomyHistoryCommand struct {
+	*BaseCommand
+	SubCmd       cmd.SubCommand       `cmd:"history" help:"Show your recent transactions."`
+	Page         cmd.Optional[int]    `cmd:"page" help:"The page to show."`
+	Counterparty cmd.Optional[string] `cmd:"counterparty" help:"Only show transactions with this player."`
+}
+
+func (e *EconomyHistoryCommand) Allow(src cmd.Source) bool {
+	return e.CheckPlayerPermission(src, "economy.command.history")
+}
+
+func (e EconomyHistoryCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, ok := e.ValidatePlayerSource(src, o)
+	if !ok {
+		return
+	}
+	reply := e.Replier(src)
+
+	// Provide immediate feedback
+	o.Printf("Loading transaction history...")
+
+	e.ExecuteAsync(func(ctx context.Context) {
+		e.showHistory(ctx, reply, p.UUID(), p.Name(), e.Page.LoadOr(1), e.Counterparty)
+	})
+}
+
+// /economy history of <username> [page] [counterparty]
+
+type EconomyHistoryOthersCommand struct {
+	*BaseCommand
+	SubCmd       cmd.SubCommand       `cmd:"history" help:"Show the recent transactions of a player."`
+	Of           cmd.SubCommand       `cmd:"of"`
+	Username     string               `cmd:"username"`
+	Page         cmd.Optional[int]    `cmd:"page" help:"The page to show."`
+	Counterparty cmd.Optional[string] `cmd:"counterparty" help:"Only show transactions with this player."`
+}
+
+func (e *EconomyHistoryOthersCommand) Allow(src cmd.Source) bool {
+	return e.CheckPermission(src, "economy.command.history.others")
+}
+
+func (e EconomyHistoryOthersCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	reply := e.Replier(src)
+
+	// Provide immediate feedback
+	o.Printf("Loading transaction history...")
+
+	e.ExecuteAsync(func(ctx context.Context) {
+		// get target uuid
+		tuid, err := e.GetUUIDByName(ctx, reply, e.Username)
+		if err != nil {
+			return
+		}
+		e.showHistory(ctx, reply, tuid, e.Username, e.Page.LoadOr(1), e.Counterparty)
+	})
+}
+
+// showHistory sends a page of the ledger entries of the target to the source.
+func (b *BaseCommand) showHistory(ctx context.Context, reply replyFunc, target uuid.UUID, targetName string, page int, counterparty cmd.Optional[string]) {
+	// get counterparty uuid
+	var cuid uuid.UUID
+	if cn, ok := counterparty.Load(); ok {
+		var err error
+		cuid, err = b.GetUUIDByName(ctx, reply, cn)
+		if err != nil {
+			return
+		}
+	}
+
+	// get history entries
+	entries, err := b.svc.GetHistory(ctx, target, cuid, page, itemCount)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrValidation):
+			reply("§c[Error] Invalid input: " + err.Error())
+		case errors.Is(err, context.DeadlineExceeded):
+			reply("§c[Error] Request timeout")
+		default:
+			reply("§c[Error] Failed to get history by internal error")
+		}
+		return
+	}
+	if len(entries) == 0 {
+		reply("§e[History] No transactions found")
+		return
+	}
+
+	// success - display results
+	reply(fmt.Sprintf("§a[History of %s - Page %d]", targetName, page))
+	for _, entry := range entries {
+		reply(b.formatHistoryEntry(entry, target))
+	}
+}
+
+// formatHistoryEntry describes a ledger entry from the perspective of the target.
+func (b *BaseCommand) formatHistoryEntry(entry economy.Transaction, target uuid.UUID) string {
+	at := entry.CreatedAt.Local().Format("2006-01-02 15:04")
+	amount := b.svc.FormatAmount(entry.Currency, entry.Amount)
+	switch entry.Type {
+	case economy.TransactionTransfer:
+		if entry.From == target {
+			return fmt.Sprintf("§7%s §c-%s§r to %s (balance %s)", at, amount, entry.ToName, b.svc.FormatAmount(entry.Currency, entry.FromBalance))
+		}
+		return fmt.Sprintf("§7%s §a+%s§r from %s (balance %s)", at, amount, entry.FromName, b.svc.FormatAmount(entry.Currency, entry.ToBalance))
+	case economy.TransactionSet:
+		if entry.Amount >= 0 {
+			amount = "+" + amount
+		}
+		return fmt.Sprintf("§7%s §e%s§r admin adjustment (balance %s)", at, amount, b.svc.FormatAmount(entry.Currency, entry.ToBalance))
+	case economy.TransactionGive:
+		return fmt.Sprintf("§7%s §a+%s§r admin grant%s (balance %s)", at, amount, formatReason(entry.Reason), b.svc.FormatAmount(entry.Currency, entry.ToBalance))
+	case economy.TransactionTake:
+		return fmt.Sprintf("§7%s §c-%s§r admin deduction%s (balance %s)", at, amount, formatReason(entry.Reason), b.svc.FormatAmount(entry.Currency, entry.FromBalance))
+	case economy.TransactionFee:
+		if entry.From == target {
+			return fmt.Sprintf("§7%s §c-%s§r transfer fee (balance %s)", at, amount, b.svc.FormatAmount(entry.Currency, entry.FromBalance))
+		}
+		return fmt.Sprintf("§7%s §a+%s§r fee from %s (balance %s)", at, amount, entry.FromName, b.svc.FormatAmount(entry.Currency, entry.ToBalance))
+	case economy.TransactionExchange:
+		if entry.From == target {
+			return fmt.Sprintf("§7%s §c-%s§r exchanged (balance %s)", at, amount, b.svc.FormatAmount(entry.Currency, entry.FromBalance))
+		}
+		return fmt.Sprintf("§7%s §a+%s§r exchanged (balance %s)", at, amount, b.svc.FormatAmount(entry.Currency, entry.ToBalance))
+	case economy.TransactionBatch:
+		if entry.From == target {
+			return fmt.Sprintf("§7%s §c-%s§r to %s%s (balance %s)", at, amount, entry.ToName, formatReason(entry.Reason), b.svc.FormatAmount(entry.Currency, entry.FromBalance))
+		}
+		return fmt.Sprintf("§7%s §a+%s§r from %s%s (balance %s)", at, amount, entry.FromName, formatReason(entry.Reason), b.svc.FormatAmount(entry.Currency, entry.ToBalance))
+	case economy.TransactionRegister:
+		return fmt.Sprintf("§7%s §a+%s§r initial balance", at, amount)
+	case economy.TransactionOpening:
+		if entry.Amount >= 0 {
+			amount = "+" + amount
+		}
+		return fmt.Sprintf("§7%s §e%s§r opening balance (balance %s)", at, amount, b.svc.FormatAmount(entry.Currency, entry.ToBalance))
+	default:
+		return fmt.Sprintf("§7%s §r%s %s", at, entry.Type, amount)
+	}
+}
+
+// formatReason formats the audit reason of a ledger entry for display.
+func formatReason(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return ": " + reason
+}
+
+// Validation
+var _ cmd.Runnable = (*EconomyHistoryCommand)(nil)
+var _ cmd.Allower = (*EconomyHistoryCommand)(nil)
+var _ cmd.Runnable = (*EconomyHistoryOthersCommand)(nil)
+var _ cmd.Allower = (*EconomyHistoryOthersCommand)(nil)
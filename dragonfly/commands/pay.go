@@ -12,17 +12,18 @@ import (
 	"github.com/skuralll/dfeconomy/economy/service"
 )
 
-// /economy pay <target> <amount>
+// /economy pay <target> <amount> [currency]
 
 type EconomyPayCommand struct {
 	*BaseCommand
-	SubCmd   cmd.SubCommand `cmd:"pay" help:"Pay a player."`
-	Username string         `cmd:"username"`
-	Amount   float64        `cmd:"amount"`
+	SubCmd   cmd.SubCommand       `cmd:"pay" help:"Pay a player."`
+	Username string               `cmd:"username"`
+	Amount   string               `cmd:"amount"`
+	Currency cmd.Optional[string] `cmd:"currency"`
 }
 
 func (e *EconomyPayCommand) Allow(src cmd.Source) bool {
-	return e.CheckPermission(src, "economy.command.pay")
+	return e.CheckPlayerPermission(src, "economy.command.pay")
 }
 
 func (e EconomyPayCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
@@ -30,37 +31,52 @@ func (e EconomyPayCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
 	if !ok {
 		return
 	}
+	reply := e.Replier(src)
+	// validate amount
+	currency := e.Currency.LoadOr("")
+	amount, err := e.svc.ParseAmount(currency, e.Amount)
+	if err != nil {
+		o.Error("Invalid input: " + err.Error())
+		return
+	}
 
 	// Provide immediate feedback
 	o.Printf("Processing payment...")
 
-	e.ExecuteAsync(p, func(ctx context.Context) {
+	e.ExecuteAsync(func(ctx context.Context) {
 		// get target uuid
-		tuid, err := e.GetUUIDByName(ctx, p, e.Username)
+		tuid, err := e.GetUUIDByName(ctx, reply, e.Username)
 		if err != nil {
 			return
 		}
 		// transfer balance
-		err = e.svc.TransferBalance(ctx, p.UUID(), tuid, e.Amount)
+		result, err := e.svc.TransferBalance(ctx, p.UUID(), tuid, currency, amount)
 		if err != nil {
 			switch {
+			case errors.Is(err, service.ErrCancelled):
+				reply("§c[Error] Payment " + err.Error())
 			case errors.Is(err, service.ErrValidation):
-				p.Message("§c[Error] Invalid input: " + err.Error())
+				reply("§c[Error] Invalid input: " + err.Error())
 			case errors.Is(err, service.ErrUnknownPlayer):
-				p.Message("§c[Error] Target player not found: " + e.Username)
+				reply("§c[Error] Target player not found: " + e.Username)
 			case errors.Is(err, context.DeadlineExceeded):
-				p.Message("§c[Error] Request timeout")
+				reply("§c[Error] Request timeout")
 			case errors.Is(err, service.ErrInternalError):
-				p.Message("§c[Error] Failed to pay by internal error")
-				slog.Error("Failed to pay", "error", err, "from", p.Name(), "to", e.Username, "amount", e.Amount)
+				reply("§c[Error] Failed to pay by internal error")
+				slog.Error("Failed to pay", "error", err, "from", p.Name(), "to", e.Username, "amount", e.svc.FormatAmount(currency, amount))
 			default:
-				p.Message("§c[Error] Failed to pay by internal error")
-				slog.Error("Failed to pay", "error", err, "from", p.Name(), "to", e.Username, "amount", e.Amount)
+				reply("§c[Error] Failed to pay by internal error")
+				slog.Error("Failed to pay", "error", err, "from", p.Name(), "to", e.Username, "amount", e.svc.FormatAmount(currency, amount))
 			}
 			return
 		}
 		// success
-		p.Message(fmt.Sprintf("§a[Success] You paid %.2f to %s", e.Amount, e.Username))
+		if result.Fee > 0 {
+			reply(fmt.Sprintf("§a[Success] You paid %s to %s (fee %s, received %s)", e.svc.FormatAmount(result.Currency, result.Amount),
+				e.Username, e.svc.FormatAmount(result.Currency, result.Fee), e.svc.FormatAmount(result.Currency, result.Received)))
+			return
+		}
+		reply(fmt.Sprintf("§a[Success] You paid %s to %s", e.svc.FormatAmount(result.Currency, result.Amount), e.Username))
 	})
 }
 
@@ -2,25 +2,36 @@ package commands
 
 import (
 	"github.com/df-mc/dragonfly/server/cmd"
-	"github.com/skuralll/dfeconomy/economy/config"
 	"github.com/skuralll/dfeconomy/economy/service"
 )
 
-func RegisterCommands(svc *service.EconomyService, cfg config.Config) {
+func RegisterCommands(svc *service.EconomyService) {
 	baseCmd := &BaseCommand{svc: svc}
 	
-	// Base commands that are always available
+	// The set command checks enable_set_cmd on every use, so it follows config reloads
 	subCommands := []cmd.Runnable{
 		&EconomyBalanceCommand{BaseCommand: baseCmd},
 		&EconomyTopCommand{BaseCommand: baseCmd},
+		&EconomyHistoryCommand{BaseCommand: baseCmd},
+		&EconomyHistoryOthersCommand{BaseCommand: baseCmd},
 		&EconomyPayCommand{BaseCommand: baseCmd},
+		&EconomyExchangeCommand{BaseCommand: baseCmd},
+		&EconomyGiveCommand{BaseCommand: baseCmd},
+		&EconomyTakeForceCommand{BaseCommand: baseCmd}, // before take so "force" is not read as a username
+		&EconomyTakeCommand{BaseCommand: baseCmd},
+		&EconomyTreasuryCommand{BaseCommand: baseCmd},
+		&EconomyTreasuryTransferCommand{BaseCommand: baseCmd},
+		&EconomySetCommand{BaseCommand: baseCmd},
+		&EconomyReloadCommand{BaseCommand: baseCmd},
+		&EconomyVerifyCommand{BaseCommand: baseCmd},
+		&EconomyBankCreateCommand{BaseCommand: baseCmd},
+		&EconomyBankDepositCommand{BaseCommand: baseCmd},
+		&EconomyBankWithdrawCommand{BaseCommand: baseCmd},
+		&EconomyBankInviteCommand{BaseCommand: baseCmd},
+		&EconomyBankKickCommand{BaseCommand: baseCmd},
+		&EconomyBankInfoCommand{BaseCommand: baseCmd},
 		&EconomyCommand{baseCmd},
 	}
 	
-	// Conditionally add set command
-	if cfg.EnableSetCmd {
-		subCommands = append(subCommands, &EconomySetCommand{BaseCommand: baseCmd})
-	}
-	
 	cmd.Register(cmd.New("economy", "Displays economy-related information.", nil, subCommands...))
 }
\ No newline at end of file
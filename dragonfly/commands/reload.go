@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/df-mc/dragonfly/server/cmd"
+	"github.com/df-mc/dragonfly/server/world"
+
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+// /economy reload
+
+type EconomyReloadCommand struct {
+	*BaseCommand
+	SubCmd cmd.SubCommand `cmd:"reload" help:"Reload the economy config."`
+}
+
+func (e *EconomyReloadCommand) Allow(src cmd.Source) bool {
+	return e.CheckPermission(src, "economy.command.reload")
+}
+
+func (e EconomyReloadCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	reply := e.Replier(src)
+
+	// Provide immediate feedback
+	o.Printf("Reloading economy config...")
+
+	_, actorName := e.SourceActor(src)
+	e.ExecuteAsync(func(ctx context.Context) {
+		if err := e.svc.ReloadConfig(ctx); err != nil {
+			switch {
+			case errors.Is(err, service.ErrValidation):
+				reply("§c[Error] Invalid config: " + err.Error())
+			case errors.Is(err, context.DeadlineExceeded):
+				reply("§c[Error] Request timeout")
+			default:
+				reply("§c[Error] Failed to reload config by internal error")
+				slog.Error("Failed to reload config", "error", err, "actor", actorName)
+			}
+			return
+		}
+		// success
+		reply("§a[Success] Reloaded the economy config")
+	})
+}
+
+// Validation
+var _ cmd.Runnable = (*EconomyReloadCommand)(nil)
+var _ cmd.Allower = (*EconomyReloadCommand)(nil)
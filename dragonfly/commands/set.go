@@ -9,26 +9,31 @@ import (
 	"github.com/df-mc/dragonfly/server/world"
 )
 
-// /economy set <target> <amount>
+// /economy set <target> <amount> [currency]
 
 type EconomySetCommand struct {
 	*BaseCommand
-	SubCmd   cmd.SubCommand `cmd:"set" help:"Set the balance of a player."`
-	Username string         `cmd:"username"`
-	Amount   float64        `cmd:"amount"`
+	SubCmd   cmd.SubCommand       `cmd:"set" help:"Set the balance of a player."`
+	Username string               `cmd:"username"`
+	Amount   string               `cmd:"amount"`
+	Currency cmd.Optional[string] `cmd:"currency"`
 }
 
+// Allow hides the command while enable_set_cmd is off, which may change on reload.
 func (e *EconomySetCommand) Allow(src cmd.Source) bool {
-	return e.CheckPermission(src, "economy.command.set")
+	return e.svc.Config().EnableSetCmd && e.CheckPermission(src, "economy.command.set")
 }
 
 func (e EconomySetCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
-	p, ok := e.ValidatePlayerSource(src, o)
-	if !ok {
+	reply := e.Replier(src)
+	// validate amount
+	currency := e.Currency.LoadOr("")
+	amount, err := e.svc.ParseAmount(currency, e.Amount)
+	if err != nil {
+		o.Error("Invalid input: " + err.Error())
 		return
 	}
-	// validate amount
-	if e.Amount < 0 {
+	if amount < 0 {
 		o.Error("Amount must be at least 0")
 		return
 	}
@@ -36,24 +41,25 @@ func (e EconomySetCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
 	// Provide immediate feedback
 	o.Printf("Processing balance update...")
 
-	e.ExecuteAsync(p, func(ctx context.Context) {
+	actor, _ := e.SourceActor(src)
+	e.ExecuteAsync(func(ctx context.Context) {
 		// get target uuid
-		tuid, err := e.GetUUIDByName(ctx, p, e.Username)
+		tuid, err := e.GetUUIDByName(ctx, reply, e.Username)
 		if err != nil {
 			return
 		}
 		// set balance
-		err = e.svc.SetBalance(ctx, tuid, e.Username, float64(e.Amount))
+		entry, err := e.svc.SetBalance(ctx, tuid, e.Username, currency, amount, actor)
 		if err != nil {
 			if errors.Is(err, context.DeadlineExceeded) {
-				p.Message("§c[Error] Request timeout")
+				reply("§c[Error] Request timeout")
 			} else {
-				p.Message("§c[Error] Failed to set balance: " + err.Error())
+				reply("§c[Error] Failed to set balance: " + err.Error())
 			}
 			return
 		}
 		// success
-		p.Message(fmt.Sprintf("§a[Success] Set balance of %s to %.2f", e.Username, e.Amount))
+		reply(fmt.Sprintf("§a[Success] Set balance of %s to %s", e.Username, e.svc.FormatAmount(entry.Currency, entry.ToBalance)))
 	})
 }
 
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/df-mc/dragonfly/server/cmd"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/google/uuid"
+
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+// /economy treasury [account]
+
+type EconomyTreasuryCommand struct {
+	*BaseCommand
+	SubCmd  cmd.SubCommand       `cmd:"treasury" help:"Show the balances of system accounts."`
+	Account cmd.Optional[string] `cmd:"account" help:"Only show this system account."`
+}
+
+func (e *EconomyTreasuryCommand) Allow(src cmd.Source) bool {
+	return e.CheckPermission(src, "economy.command.treasury")
+}
+
+func (e EconomyTreasuryCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	reply := e.Replier(src)
+	// validate account
+	names := e.svc.SystemAccounts()
+	if name, ok := e.Account.Load(); ok {
+		if _, err := e.svc.SystemAccount(name); err != nil {
+			o.Error("Invalid input: " + err.Error())
+			return
+		}
+		names = []string{name}
+	}
+
+	// Provide immediate feedback
+	o.Printf("Fetching system accounts...")
+
+	e.ExecuteAsync(func(ctx context.Context) {
+		for _, name := range names {
+			id, _ := e.svc.SystemAccount(name)
+			for _, c := range e.svc.Currencies() {
+				amount, err := e.svc.GetBalance(ctx, id, c.Name)
+				if err != nil {
+					if errors.Is(err, context.DeadlineExceeded) {
+						reply("§c[Error] Request timeout")
+					} else {
+						reply("§c[Error] Failed to get balance")
+					}
+					return
+				}
+				reply(fmt.Sprintf("§a[Treasury] %s: %s", name, c.Format(amount)))
+			}
+		}
+	})
+}
+
+// /economy treasury transfer <from> <to> <amount> [currency]
+
+type EconomyTreasuryTransferCommand struct {
+	*BaseCommand
+	SubCmd   cmd.SubCommand       `cmd:"treasury" help:"Move money from or to a system account."`
+	Transfer cmd.SubCommand       `cmd:"transfer"`
+	From     string               `cmd:"from" help:"System account or player that pays, one side must be a system account."`
+	To       string               `cmd:"to" help:"System account or player that receives, one side must be a system account."`
+	Amount   string               `cmd:"amount"`
+	Currency cmd.Optional[string] `cmd:"currency"`
+}
+
+func (e *EconomyTreasuryTransferCommand) Allow(src cmd.Source) bool {
+	return e.CheckPermission(src, "economy.command.treasury")
+}
+
+func (e EconomyTreasuryTransferCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	reply := e.Replier(src)
+	// validate amount
+	currency := e.Currency.LoadOr("")
+	amount, err := e.svc.ParseAmount(currency, e.Amount)
+	if err != nil {
+		o.Error("Invalid input: " + err.Error())
+		return
+	}
+	// validate accounts, payments between players go through /pay with its fee and checks
+	_, fromErr := e.svc.SystemAccount(e.From)
+	_, toErr := e.svc.SystemAccount(e.To)
+	if fromErr != nil && toErr != nil {
+		o.Error("Invalid input: from or to must be a system account")
+		return
+	}
+
+	// Provide immediate feedback
+	o.Printf("Processing transfer...")
+
+	actor, actorName := e.SourceActor(src)
+	e.ExecuteAsync(func(ctx context.Context) {
+		// get account uuids
+		fromID, err := e.resolveAccount(ctx, reply, e.From)
+		if err != nil {
+			return
+		}
+		toID, err := e.resolveAccount(ctx, reply, e.To)
+		if err != nil {
+			return
+		}
+		// transfer balance on behalf of the admin
+		result, err := e.svc.TransferBalanceAs(ctx, fromID, toID, currency, amount, actor)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrCancelled):
+				reply("§c[Error] Transfer " + err.Error())
+			case errors.Is(err, service.ErrValidation):
+				reply("§c[Error] Invalid input: " + err.Error())
+			case errors.Is(err, context.DeadlineExceeded):
+				reply("§c[Error] Request timeout")
+			default:
+				reply("§c[Error] Failed to transfer by internal error")
+				slog.Error("Failed to transfer", "error", err, "actor", actorName, "from", e.From, "to", e.To, "amount", e.svc.FormatAmount(currency, amount))
+			}
+			return
+		}
+		// success
+		reply(fmt.Sprintf("§a[Success] Moved %s from %s to %s", e.svc.FormatAmount(result.Currency, result.Received), e.From, e.To))
+	})
+}
+
+// resolveAccount resolves a system account name, falling back to a player name.
+func (b *BaseCommand) resolveAccount(ctx context.Context, reply replyFunc, name string) (uuid.UUID, error) {
+	if id, err := b.svc.SystemAccount(name); err == nil {
+		return id, nil
+	}
+	return b.GetUUIDByName(ctx, reply, name)
+}
+
+// Validation
+var _ cmd.Runnable = (*EconomyTreasuryCommand)(nil)
+var _ cmd.Allower = (*EconomyTreasuryCommand)(nil)
+var _ cmd.Runnable = (*EconomyTreasuryTransferCommand)(nil)
+var _ cmd.Allower = (*EconomyTreasuryTransferCommand)(nil)
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/df-mc/dragonfly/server/cmd"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/skuralll/dfeconomy/economy"
+)
+
+const (
+	// VerifyCommandTimeout limits /economy verify, which reads the whole database.
+	VerifyCommandTimeout = 2 * time.Minute
+	// maxVerifyLines is the number of problems of each kind listed in chat, the rest
+	// is only counted.
+	maxVerifyLines = 5
+)
+
+// /economy verify
+
+type EconomyVerifyCommand struct {
+	*BaseCommand
+	SubCmd cmd.SubCommand `cmd:"verify" help:"Check that every balance matches the ledger."`
+}
+
+func (e *EconomyVerifyCommand) Allow(src cmd.Source) bool {
+	return e.CheckPermission(src, "economy.command.verify")
+}
+
+func (e EconomyVerifyCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	reply := e.Replier(src)
+
+	// Provide immediate feedback
+	o.Printf("Verifying balances...")
+
+	_, actorName := e.SourceActor(src)
+	e.ExecuteAsyncWithTimeout(VerifyCommandTimeout, func(ctx context.Context) {
+		report, err := e.svc.VerifyInvariants(ctx)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				reply("§c[Error] Request timeout")
+			} else {
+				reply("§c[Error] Failed to verify balances by internal error")
+				slog.Error("Failed to verify invariants", "error", err, "actor", actorName)
+			}
+			return
+		}
+		// display results
+		e.replyAudits(reply, "§c", report.Mismatched, func(m economy.BalanceAudit) string {
+			return fmt.Sprintf("balance %s, ledger %s", e.svc.FormatAmount(m.Currency, m.Balance), e.svc.FormatAmount(m.Currency, m.Ledger))
+		})
+		e.replyAudits(reply, "§e", report.Negative, func(n economy.BalanceAudit) string {
+			return "negative balance of " + e.svc.FormatAmount(n.Currency, n.Balance)
+		})
+		for _, c := range report.Overflow {
+			reply("§c[Verify] Supply of " + c + " overflows")
+		}
+		currencies := make([]string, 0, len(report.Supply))
+		for c := range report.Supply {
+			currencies = append(currencies, c)
+		}
+		slices.Sort(currencies)
+		for _, c := range currencies {
+			reply(fmt.Sprintf("§a[Verify] Supply of %s: %s", c, e.svc.FormatAmount(c, report.Supply[c])))
+		}
+
+		if report.Conserved() {
+			reply(fmt.Sprintf("§a[Success] %d balances match the ledger", report.Balances))
+		} else {
+			reply(fmt.Sprintf("§c[Error] %d of %d balances do not match the ledger", len(report.Mismatched), report.Balances))
+		}
+		if !report.NonNegative() {
+			reply(fmt.Sprintf("§c[Error] %d of %d balances are negative", len(report.Negative), report.Balances))
+		}
+		if !report.OK() {
+			slog.Warn("Economy invariants violated", "mismatched", len(report.Mismatched), "negative", len(report.Negative), "overflow", report.Overflow, "actor", actorName)
+		}
+	})
+}
+
+// replyAudits lists the first problematic balances and counts the rest.
+func (e EconomyVerifyCommand) replyAudits(reply replyFunc, color string, audits []economy.BalanceAudit, describe func(economy.BalanceAudit) string) {
+	for _, a := range audits[:min(len(audits), maxVerifyLines)] {
+		reply(fmt.Sprintf("%s[Verify] %s %s: %s", color, accountLabel(a.Name, a.Account.String()), a.Currency, describe(a)))
+	}
+	if more := len(audits) - maxVerifyLines; more > 0 {
+		reply(fmt.Sprintf("%s[Verify] ... and %d more, run ecoadmin verify for the full list", color, more))
+	}
+}
+
+// accountLabel names an account, falling back to its UUID when it has no name.
+func accountLabel(name, id string) string {
+	if name == "" {
+		return id
+	}
+	return name
+}
+
+// Validation
+var _ cmd.Runnable = (*EconomyVerifyCommand)(nil)
+var _ cmd.Allower = (*EconomyVerifyCommand)(nil)
@@ -0,0 +1,40 @@
+package economy
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// AccountKind distinguishes player accounts from accounts owned by the server.
+type AccountKind string
+
+const (
+	AccountPlayer AccountKind = "player" // Account of a player, created on join
+	AccountSystem AccountKind = "system" // Server-owned account such as the treasury
+	AccountBank   AccountKind = "bank"   // Account shared by the members of a bank
+)
+
+// TreasuryAccountName is the system account that always exists.
+const TreasuryAccountName = "treasury"
+
+var systemAccountNamePattern = regexp.MustCompile(`^[a-z0-9_]{1,16}$`)
+
+// systemAccountNamespace scopes the name-based UUIDs of system accounts so they
+// cannot collide with player UUIDs.
+var systemAccountNamespace = uuid.MustParse("7c1d3f2e-5b0a-4e8f-9a61-2d4c8b9e0f13")
+
+// SystemAccountID returns the reserved UUID of the system account with the given name.
+// The UUID is derived from the name, so it is stable across restarts and servers.
+func SystemAccountID(name string) uuid.UUID {
+	return uuid.NewSHA1(systemAccountNamespace, []byte(name))
+}
+
+// ValidateSystemAccountName checks that name can be used as a system account name.
+func ValidateSystemAccountName(name string) error {
+	if !systemAccountNamePattern.MatchString(name) {
+		return fmt.Errorf("system account name %q must be 1-16 characters of a-z, 0-9 or _", name)
+	}
+	return nil
+}
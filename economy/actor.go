@@ -0,0 +1,21 @@
+package economy
+
+import "context"
+
+// MaxActorNameLength is the maximum length of an actor name in bytes.
+const MaxActorNameLength = 64
+
+type actorNameContext struct{}
+
+// WithActorName returns a context naming the client that initiates mutations, e.g.
+// the API key of a remote request. Ledger entries written with the context record
+// the name, so mutations by clients that are not players can still be attributed.
+func WithActorName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, actorNameContext{}, name)
+}
+
+// ActorName returns the actor name carried by ctx, empty if there is none.
+func ActorName(ctx context.Context) string {
+	name, _ := ctx.Value(actorNameContext{}).(string)
+	return name
+}
@@ -0,0 +1,70 @@
+package economy
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// BankRole is the role of a member of a shared bank account. Every role includes
+// the permissions of the roles below it.
+type BankRole string
+
+const (
+	BankRoleDeposit  BankRole = "deposit"  // May deposit money
+	BankRoleWithdraw BankRole = "withdraw" // May also withdraw money
+	BankRoleManage   BankRole = "manage"   // May also invite and kick members
+	BankRoleOwner    BankRole = "owner"    // Creator of the bank, may also manage managers
+)
+
+var bankRoleRanks = map[BankRole]int{
+	BankRoleDeposit:  1,
+	BankRoleWithdraw: 2,
+	BankRoleManage:   3,
+	BankRoleOwner:    4,
+}
+
+// Valid reports whether r is a known role.
+func (r BankRole) Valid() bool {
+	_, ok := bankRoleRanks[r]
+	return ok
+}
+
+// Allows reports whether r grants at least the permissions of required.
+func (r BankRole) Allows(required BankRole) bool {
+	return r.Valid() && bankRoleRanks[r] >= bankRoleRanks[required]
+}
+
+var bankNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+
+// ValidateBankName checks that name can be used as a bank name.
+func ValidateBankName(name string) error {
+	if !bankNamePattern.MatchString(name) {
+		return fmt.Errorf("bank name %q must be 1-16 characters of A-Z, a-z, 0-9 or _", name)
+	}
+	return nil
+}
+
+type BankMember struct {
+	UUID uuid.UUID // Player's UUID
+	Name string    // Display name
+	Role BankRole  // Granted role
+}
+
+// Bank is an account shared by several players.
+type Bank struct {
+	UUID    uuid.UUID    // Account UUID
+	Name    string       // Unique bank name
+	Members []BankMember // Members including the owner
+}
+
+// Role returns the role of the player, or an empty role when they are not a member.
+func (b Bank) Role(id uuid.UUID) BankRole {
+	for _, m := range b.Members {
+		if m.UUID == id {
+			return m.Role
+		}
+	}
+	return ""
+}
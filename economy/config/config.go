@@ -1,8 +1,149 @@
 package config
 
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/skuralll/dfeconomy/economy"
+)
+
 type Config struct {
-	DBType         string  `toml:"db_type"`         // Database type: sqlite, mysql, postgres
-	DBDSN          string  `toml:"db_dsn"`          // Path to the database file
-	DefaultBalance float64 `toml:"default_balance"` // Default amount of money for new users
-	EnableSetCmd   bool    `toml:"enable_set_cmd"`  // Enable /economy set command
+	DBType         string         `toml:"db_type"`         // Database type: sqlite, mysql, postgres
+	DBDSN          string         `toml:"db_dsn"`          // Path to the database file
+	DefaultBalance float64        `toml:"default_balance"` // Default amount of money for new users
+	Scale          int            `toml:"scale"`           // Number of decimal places for balances, 0 uses economy.DefaultScale
+	Currencies     []Currency     `toml:"currencies"`      // Registered currencies, the first one is the default
+	ExchangeRates  []ExchangeRate `toml:"exchange_rates"`  // Allowed currency conversions
+	TransferFee    TransferFee    `toml:"transfer_fee"`    // Fee charged on /economy pay
+	SystemAccounts []string       `toml:"system_accounts"` // Server-owned accounts in addition to the treasury
+	EnableSetCmd   bool           `toml:"enable_set_cmd"`  // Enable /economy set command
+	Console        ConsolePolicy  `toml:"console"`         // Permissions of the console and other non-player sources
+	HTTP           HTTPConfig     `toml:"http"`            // REST API served by the httpapi package
+}
+
+type Currency struct {
+	Name           string  `toml:"name"`            // Identifier used in commands, e.g. coins
+	Symbol         string  `toml:"symbol"`          // Display symbol, e.g. $
+	Decimals       int     `toml:"decimals"`        // Number of decimal places
+	DefaultBalance float64 `toml:"default_balance"` // Default amount of this currency for new users
+}
+
+type ExchangeRate struct {
+	From string `toml:"from"` // Currency that is paid
+	To   string `toml:"to"`   // Currency that is received
+	Rate string `toml:"rate"` // Units of To per unit of From as a decimal or fraction, e.g. "0.01" or "1/3"
+	Fee  string `toml:"fee"`  // Optional fraction of the converted amount kept as fee, e.g. "0.05"
+}
+
+// TransferFee is charged to the sender of a payment: the receiver gets the amount
+// minus Flat + amount * Percent. Tiers, when set, replace Flat and Percent based on
+// the amount. Amounts are decimal strings in units of the transferred currency.
+type TransferFee struct {
+	Percent string    `toml:"percent"` // Fraction of the amount, e.g. "0.02"
+	Flat    string    `toml:"flat"`    // Fixed amount, e.g. "1.00"
+	Tiers   []FeeTier `toml:"tiers"`   // Fee by amount, the tier with the highest reached Min applies
+	Sink    string    `toml:"sink"`    // Where fees go: burn (default) or the name of a system account
+}
+
+type FeeTier struct {
+	Min     string `toml:"min"`     // Minimum amount this tier applies to
+	Percent string `toml:"percent"` // Fraction of the amount
+	Flat    string `toml:"flat"`    // Fixed amount
+}
+
+// ConsolePolicy decides which commands non-player sources such as the server console
+// or scripts may run. Commands that act on the balance of the source itself always
+// require a player.
+type ConsolePolicy struct {
+	Disabled    bool     `toml:"disabled"`    // Reject every command from non-player sources
+	Permissions []string `toml:"permissions"` // Granted permissions, e.g. "economy.command.set" or "economy.command.*". Empty grants all
+}
+
+// Allows reports whether non-player sources have the permission.
+func (p ConsolePolicy) Allows(permission string) bool {
+	if p.Disabled {
+		return false
+	}
+	if len(p.Permissions) == 0 {
+		return true
+	}
+	return matchAny(p.Permissions, permission)
+}
+
+// HTTPConfig configures the REST API. API keys are read on every request, so they
+// can be rotated with a config reload; the address requires a restart. The gRPC API
+// of the grpcapi package accepts the same keys.
+type HTTPConfig struct {
+	Enabled bool     `toml:"enabled"`  // Start the API with the server
+	Addr    string   `toml:"addr"`     // Listen address, e.g. "127.0.0.1:8080"
+	APIKeys []APIKey `toml:"api_keys"` // Accepted API keys
+}
+
+// Scopes granted to API keys in APIKey.Scopes
+const (
+	ScopeBalanceRead  = "balance:read"  // Read balances and look up players
+	ScopeBalanceWrite = "balance:write" // Set, withdraw and deposit balances
+	ScopeTransfer     = "transfer"      // Transfer money between players
+	ScopeTopRead      = "top:read"      // Read the leaderboard
+	ScopeHistoryRead  = "history:read"  // Read transaction histories
+)
+
+// APIKey authenticates a client of the REST or gRPC API and limits what it may do.
+type APIKey struct {
+	Name   string   `toml:"name"`   // Client name shown in logs, e.g. "discord-bot"
+	Key    string   `toml:"key"`    // Secret sent as bearer token
+	Scopes []string `toml:"scopes"` // Granted scopes, e.g. "balance:read" or "balance:*". Empty grants none
+}
+
+// Allows reports whether the key grants the scope.
+func (k APIKey) Allows(scope string) bool {
+	return matchAny(k.Scopes, scope)
+}
+
+// FindAPIKey returns the key matching the secret sent by a client. Secrets are
+// compared in constant time.
+func FindAPIKey(keys []APIKey, secret string) (APIKey, bool) {
+	if secret == "" {
+		return APIKey{}, false
+	}
+	for _, key := range keys {
+		if subtle.ConstantTimeCompare([]byte(key.Key), []byte(secret)) == 1 {
+			return key, true
+		}
+	}
+	return APIKey{}, false
+}
+
+// matchAny reports whether any pattern matches name. A trailing * matches any suffix.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == name {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MoneyScale returns the configured number of decimal places, falling back to the default.
+func (c Config) MoneyScale() int {
+	if c.Scale <= 0 {
+		return economy.DefaultScale
+	}
+	return c.Scale
+}
+
+// CurrencyList returns the registered currencies. Without any configured currency,
+// a single currency is built from DefaultBalance and Scale.
+func (c Config) CurrencyList() []Currency {
+	if len(c.Currencies) > 0 {
+		return c.Currencies
+	}
+	return []Currency{{
+		Name:           economy.DefaultCurrencyName,
+		Decimals:       c.MoneyScale(),
+		DefaultBalance: c.DefaultBalance,
+	}}
 }
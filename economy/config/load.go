@@ -0,0 +1,241 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"github.com/skuralll/dfeconomy/economy"
+)
+
+// EnvPrefix prefixes environment variables overriding top-level settings, e.g.
+// DFECONOMY_DB_DSN overrides db_dsn.
+const EnvPrefix = "DFECONOMY_"
+
+// Section is the table read from files that also hold other settings, such as the
+// config.toml of the server.
+const Section = "economy"
+
+// MinAPIKeyLength is the minimum length of a REST API key, so keys cannot be guessed.
+const MinAPIKeyLength = 16
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Default returns the configuration written to new config files.
+func Default() Config {
+	return Config{
+		DBType:         "sqlite",
+		DBDSN:          "./economy.db",
+		DefaultBalance: 100,
+		Scale:          economy.DefaultScale,
+		HTTP:           HTTPConfig{Addr: "127.0.0.1:8080"},
+	}
+}
+
+// Load reads the configuration from a TOML file. Settings are read from the [economy]
+// table if present, otherwise from the whole file. A missing file is created with the
+// defaults, and a file holding only other settings, such as the config.toml of the
+// server, gets an [economy] table with the defaults appended. DFECONOMY_* environment
+// variables override the file, and the result is validated.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		data, err := toml.Marshal(cfg)
+		if err != nil {
+			return Config{}, fmt.Errorf("encode default config: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return Config{}, fmt.Errorf("create default config: %w", err)
+		}
+	case err != nil:
+		return Config{}, fmt.Errorf("read config: %w", err)
+	default:
+		var found bool
+		if cfg, found, err = parse(data); err != nil {
+			return Config{}, err
+		}
+		if !found {
+			if err := appendSection(path, data, cfg); err != nil {
+				return Config{}, err
+			}
+		}
+	}
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Parse decodes a TOML document, reading the [economy] table if present. Missing
+// settings keep their defaults.
+func Parse(data []byte) (Config, error) {
+	cfg, _, err := parse(data)
+	return cfg, err
+}
+
+// parse decodes a TOML document like Parse and reports whether it holds economy
+// settings, either in the [economy] table or at the top level.
+func parse(data []byte) (Config, bool, error) {
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return Config{}, false, fmt.Errorf("decode config: %w", err)
+	}
+	found := false
+	if section, ok := tree.Get(Section).(*toml.Tree); ok {
+		tree, found = section, true
+	} else {
+		for _, key := range tree.Keys() {
+			if _, ok := settingKeys()[key]; ok {
+				found = true
+				break
+			}
+		}
+	}
+	cfg := Default()
+	if err := tree.Unmarshal(&cfg); err != nil {
+		return Config{}, false, fmt.Errorf("decode config: %w", err)
+	}
+	return cfg, found, nil
+}
+
+// settingKeys returns the TOML keys of the top-level settings.
+func settingKeys() map[string]struct{} {
+	t := reflect.TypeFor[Config]()
+	keys := make(map[string]struct{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if key, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ","); key != "" && key != "-" {
+			keys[key] = struct{}{}
+		}
+	}
+	return keys
+}
+
+// appendSection appends cfg as the [economy] table to the file at path, which holds
+// data and no economy settings.
+func appendSection(path string, data []byte, cfg Config) error {
+	section, err := toml.Marshal(struct {
+		Economy Config `toml:"economy"`
+	}{cfg})
+	if err != nil {
+		return fmt.Errorf("encode default config: %w", err)
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		section = append([]byte("\n"), section...)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fmt.Errorf("append default config: %w", err)
+	}
+	if _, err := f.Write(section); err != nil {
+		f.Close()
+		return fmt.Errorf("append default config: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("append default config: %w", err)
+	}
+	return nil
+}
+
+// ApplyEnv overrides top-level string, number and bool settings with the environment
+// variables named after their TOML key, e.g. DFECONOMY_DEFAULT_BALANCE.
+func (c *Config) ApplyEnv(lookup func(key string) (string, bool)) error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		env := EnvPrefix + strings.ToUpper(key)
+		raw, ok := lookup(env)
+		if !ok {
+			continue
+		}
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(raw)
+		case reflect.Int:
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				return fmt.Errorf("%w: %s must be an integer", ErrInvalidConfig, env)
+			}
+			field.SetInt(int64(n))
+		case reflect.Float64:
+			f, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return fmt.Errorf("%w: %s must be a number", ErrInvalidConfig, env)
+			}
+			field.SetFloat(f)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				return fmt.Errorf("%w: %s must be true or false", ErrInvalidConfig, env)
+			}
+			field.SetBool(b)
+		}
+	}
+	return nil
+}
+
+// Validate checks the connection settings, default balance and scale.
+func (c Config) Validate() error {
+	switch c.DBType {
+	case "sqlite", "mysql", "postgres":
+	default:
+		return fmt.Errorf("%w: db_type %q must be sqlite, mysql or postgres", ErrInvalidConfig, c.DBType)
+	}
+	if strings.TrimSpace(c.DBDSN) == "" {
+		return fmt.Errorf("%w: db_dsn cannot be empty", ErrInvalidConfig)
+	}
+	return c.ValidateSettings()
+}
+
+// ValidateSettings checks the default balance, scale and API keys but not the
+// connection settings, which are unused when the database is provided by the caller.
+func (c Config) ValidateSettings() error {
+	if math.IsNaN(c.DefaultBalance) || math.IsInf(c.DefaultBalance, 0) {
+		return fmt.Errorf("%w: default_balance must be a valid number", ErrInvalidConfig)
+	}
+	if c.DefaultBalance < 0 {
+		return fmt.Errorf("%w: default_balance cannot be negative", ErrInvalidConfig)
+	}
+	if c.Scale < 0 || c.Scale > economy.MaxScale {
+		return fmt.Errorf("%w: scale must be between 0 and %d", ErrInvalidConfig, economy.MaxScale)
+	}
+	return c.HTTP.validate()
+}
+
+// validate checks the listen address of an enabled API and every API key.
+func (h HTTPConfig) validate() error {
+	if h.Enabled && strings.TrimSpace(h.Addr) == "" {
+		return fmt.Errorf("%w: http.addr cannot be empty", ErrInvalidConfig)
+	}
+	keys := make(map[string]bool, len(h.APIKeys))
+	for _, k := range h.APIKeys {
+		if strings.TrimSpace(k.Name) == "" {
+			return fmt.Errorf("%w: http.api_keys name cannot be empty", ErrInvalidConfig)
+		}
+		if len(k.Name) > economy.MaxActorNameLength {
+			return fmt.Errorf("%w: http.api_keys name %s must be at most %d bytes", ErrInvalidConfig, k.Name, economy.MaxActorNameLength)
+		}
+		if len(k.Key) < MinAPIKeyLength {
+			return fmt.Errorf("%w: http.api_keys key of %s must be at least %d characters", ErrInvalidConfig, k.Name, MinAPIKeyLength)
+		}
+		if keys[k.Key] {
+			return fmt.Errorf("%w: http.api_keys key of %s is used twice", ErrInvalidConfig, k.Name)
+		}
+		keys[k.Key] = true
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package config_test
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml"
+	"github.com/skuralll/dfeconomy/economy/config"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content *string // nil leaves the file missing
+		want    func(config.Config) bool
+		err     bool
+		section bool // The file has an [economy] table afterwards
+	}{
+		{
+			name:    "missing file",
+			want:    func(c config.Config) bool { return c.DBType == "sqlite" && c.DefaultBalance == 100 },
+			section: false,
+		},
+		{
+			name:    "top-level settings",
+			content: ptr("db_type = \"sqlite\"\ndb_dsn = \"eco.db\"\ndefault_balance = 5.0\n"),
+			want:    func(c config.Config) bool { return c.DBDSN == "eco.db" && c.DefaultBalance == 5 },
+		},
+		{
+			name:    "economy section",
+			content: ptr("[server]\nname = \"Dragonfly\"\n\n[economy]\ndb_dsn = \"eco.db\"\n"),
+			want:    func(c config.Config) bool { return c.DBType == "sqlite" && c.DBDSN == "eco.db" },
+			section: true,
+		},
+		{
+			name:    "missing section",
+			content: ptr("[server]\nname = \"Dragonfly\""),
+			want:    func(c config.Config) bool { return equal(c, config.Default()) },
+			section: true,
+		},
+		{
+			name:    "empty file",
+			content: ptr(""),
+			want:    func(c config.Config) bool { return equal(c, config.Default()) },
+			section: true,
+		},
+		{
+			name:    "invalid toml",
+			content: ptr("db_type = "),
+			err:     true,
+		},
+		{
+			name:    "invalid settings",
+			content: ptr("[economy]\ndb_type = \"oracle\"\n"),
+			err:     true,
+			section: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if tt.content != nil {
+				if err := os.WriteFile(path, []byte(*tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			cfg, err := config.Load(path)
+			if tt.err {
+				if !errors.Is(err, config.ErrInvalidConfig) && (err == nil || !strings.HasPrefix(err.Error(), "decode config")) {
+					t.Fatalf("Load: got %v, want an error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !tt.want(cfg) {
+				t.Errorf("Load = %+v", cfg)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(string(data), "[economy]"); got != tt.section {
+				t.Errorf("file has an [economy] table: %v, want %v\n%s", got, tt.section, data)
+			}
+			if tt.content != nil && !strings.HasPrefix(string(data), *tt.content) {
+				t.Errorf("existing settings were not kept:\n%s", data)
+			}
+			// Loading again reads what the first load wrote and changes nothing
+			again, err := config.Load(path)
+			if err != nil {
+				t.Fatalf("Load again: %v", err)
+			}
+			if !equal(again, cfg) {
+				t.Errorf("Load again = %+v, want %+v", again, cfg)
+			}
+			if after, _ := os.ReadFile(path); string(after) != string(data) {
+				t.Errorf("Load again changed the file:\n%s", after)
+			}
+		})
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "economy.toml")
+	if err := os.WriteFile(path, []byte("db_dsn = \"file.db\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DFECONOMY_DB_DSN", "env.db")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.DBDSN != "env.db" {
+		t.Errorf("db_dsn = %q, want the environment variable", cfg.DBDSN)
+	}
+
+	t.Setenv("DFECONOMY_DB_TYPE", "oracle")
+	if _, err := config.Load(path); !errors.Is(err, config.ErrInvalidConfig) {
+		t.Errorf("Load with an invalid override: got %v, want %v", err, config.ErrInvalidConfig)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want func(config.Config) bool
+		err  string // Substring of the error, empty when the overrides apply
+	}{
+		{
+			name: "string",
+			env:  map[string]string{"DFECONOMY_DB_TYPE": "postgres", "DFECONOMY_DB_DSN": "host=db"},
+			want: func(c config.Config) bool { return c.DBType == "postgres" && c.DBDSN == "host=db" },
+		},
+		{
+			name: "numbers and bools",
+			env:  map[string]string{"DFECONOMY_SCALE": "4", "DFECONOMY_DEFAULT_BALANCE": "12.5", "DFECONOMY_ENABLE_SET_CMD": "true"},
+			want: func(c config.Config) bool { return c.Scale == 4 && c.DefaultBalance == 12.5 && c.EnableSetCmd },
+		},
+		{
+			name: "unknown keys",
+			env:  map[string]string{"DFECONOMY_UNKNOWN": "1", "DFECONOMY_DBTYPE": "mysql", "db_type": "mysql", "DFECONOMY_HTTP": "x"},
+			want: func(c config.Config) bool { return c.DBType == "sqlite" && c.HTTP.Addr == config.Default().HTTP.Addr },
+		},
+		{
+			name: "bad int",
+			env:  map[string]string{"DFECONOMY_SCALE": "2.5"},
+			err:  "DFECONOMY_SCALE must be an integer",
+		},
+		{
+			name: "bad number",
+			env:  map[string]string{"DFECONOMY_DEFAULT_BALANCE": "lots"},
+			err:  "DFECONOMY_DEFAULT_BALANCE must be a number",
+		},
+		{
+			name: "bad bool",
+			env:  map[string]string{"DFECONOMY_ENABLE_SET_CMD": "yes please"},
+			err:  "DFECONOMY_ENABLE_SET_CMD must be true or false",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			err := cfg.ApplyEnv(func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			})
+			if tt.err != "" {
+				if !errors.Is(err, config.ErrInvalidConfig) || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ApplyEnv: got %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyEnv: %v", err)
+			}
+			if !tt.want(cfg) {
+				t.Errorf("ApplyEnv = %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*config.Config)
+		err    string // Substring of the error, empty when valid
+	}{
+		{"defaults", func(c *config.Config) {}, ""},
+		{"mysql", func(c *config.Config) { c.DBType = "mysql" }, ""},
+		{"postgres", func(c *config.Config) { c.DBType = "postgres" }, ""},
+		{"missing db_type", func(c *config.Config) { c.DBType = "" }, "db_type"},
+		{"invalid db_type", func(c *config.Config) { c.DBType = "SQLite" }, "db_type"},
+		{"missing db_dsn", func(c *config.Config) { c.DBDSN = "" }, "db_dsn"},
+		{"blank db_dsn", func(c *config.Config) { c.DBDSN = "  " }, "db_dsn"},
+		{"zero default_balance", func(c *config.Config) { c.DefaultBalance = 0 }, ""},
+		{"negative default_balance", func(c *config.Config) { c.DefaultBalance = -1 }, "default_balance"},
+		{"NaN default_balance", func(c *config.Config) { c.DefaultBalance = math.NaN() }, "default_balance"},
+		{"infinite default_balance", func(c *config.Config) { c.DefaultBalance = math.Inf(1) }, "default_balance"},
+		{"negative scale", func(c *config.Config) { c.Scale = -1 }, "scale"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, config.ErrInvalidConfig) || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Validate: got %v, want an error about %s", err, tt.err)
+			}
+		})
+	}
+}
+
+// equal compares configs by their encoding, decoded files hold empty slices where
+// Default has nil ones.
+func equal(a, b config.Config) bool {
+	ea, errA := toml.Marshal(a)
+	eb, errB := toml.Marshal(b)
+	return errA == nil && errB == nil && string(ea) == string(eb)
+}
+
+func ptr(s string) *string {
+	return &s
+}
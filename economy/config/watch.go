@@ -0,0 +1,32 @@
+package config
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Watch polls the config file every interval and calls onChange with the result of
+// Load whenever its modification time or size changes, until ctx is done. A missing
+// file is ignored rather than recreated with the defaults.
+func Watch(ctx context.Context, path string, interval time.Duration, onChange func(Config, error)) {
+	last, _ := os.Stat(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+			continue
+		}
+		last = info
+		onChange(Load(path))
+	}
+}
@@ -0,0 +1,127 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skuralll/dfeconomy/economy/config"
+)
+
+const watchInterval = 10 * time.Millisecond
+
+type loadResult struct {
+	cfg config.Config
+	err error
+}
+
+// watch writes content to a temporary config file and watches it until the test ends.
+func watch(t *testing.T, content string) (path string, results <-chan loadResult, cancel context.CancelFunc, done <-chan struct{}) {
+	t.Helper()
+	path = filepath.Join(t.TempDir(), "economy.toml")
+	writeFile(t, path, content)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan loadResult, 10)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		config.Watch(ctx, path, watchInterval, func(cfg config.Config, err error) {
+			ch <- loadResult{cfg, err}
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+	// Let Watch record the file before the test rewrites it
+	time.Sleep(5 * watchInterval)
+	return path, ch, cancel, stopped
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// next waits for the next call of onChange.
+func next(t *testing.T, results <-chan loadResult) loadResult {
+	t.Helper()
+	select {
+	case r := <-results:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("onChange was not called")
+		return loadResult{}
+	}
+}
+
+// none checks that onChange is not called for several intervals.
+func none(t *testing.T, results <-chan loadResult) {
+	t.Helper()
+	select {
+	case r := <-results:
+		t.Errorf("unexpected onChange(%+v, %v)", r.cfg, r.err)
+	case <-time.After(20 * watchInterval):
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path, results, _, _ := watch(t, "db_dsn = \"a.db\"\n")
+	none(t, results)
+
+	writeFile(t, path, "db_dsn = \"b.db\"\ndefault_balance = 7.0\n")
+	r := next(t, results)
+	if r.err != nil {
+		t.Fatalf("onChange error: %v", r.err)
+	}
+	if r.cfg.DBDSN != "b.db" || r.cfg.DefaultBalance != 7 {
+		t.Errorf("onChange config = %+v, want the rewritten file", r.cfg)
+	}
+	none(t, results)
+}
+
+func TestWatchInvalid(t *testing.T) {
+	path, results, _, _ := watch(t, "db_dsn = \"a.db\"\n")
+
+	writeFile(t, path, "db_type = \"oracle\"\n")
+	if r := next(t, results); !errors.Is(r.err, config.ErrInvalidConfig) {
+		t.Errorf("onChange error: got %v, want %v", r.err, config.ErrInvalidConfig)
+	}
+	writeFile(t, path, "db_dsn = ")
+	if r := next(t, results); r.err == nil {
+		t.Error("onChange reported no error for invalid TOML")
+	}
+	// Fixing the file is reported again
+	writeFile(t, path, "db_dsn = \"fixed.db\"\n")
+	if r := next(t, results); r.err != nil || r.cfg.DBDSN != "fixed.db" {
+		t.Errorf("onChange(%+v, %v), want the fixed file", r.cfg, r.err)
+	}
+}
+
+func TestWatchMissingFile(t *testing.T) {
+	path, results, _, _ := watch(t, "db_dsn = \"a.db\"\n")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	none(t, results)
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Watch recreated the missing file: %v", err)
+	}
+}
+
+func TestWatchCancel(t *testing.T) {
+	path, results, cancel, done := watch(t, "db_dsn = \"a.db\"\n")
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not return after ctx was cancelled")
+	}
+	writeFile(t, path, "db_dsn = \"changed.db\"\n")
+	none(t, results)
+}
@@ -0,0 +1,46 @@
+package economy
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DefaultCurrencyName is used when no currencies are configured.
+const DefaultCurrencyName = "money"
+
+var currencyNamePattern = regexp.MustCompile(`^[a-z0-9_]{1,16}$`)
+
+// Currency describes a registered currency.
+type Currency struct {
+	Name           string // Identifier used in commands and storage, e.g. coins
+	Symbol         string // Display symbol, e.g. $
+	Decimals       int    // Number of decimal places of a minor unit
+	DefaultBalance Money  // Starting balance for new users
+}
+
+// Validate checks that the currency can be registered.
+func (c Currency) Validate() error {
+	if !currencyNamePattern.MatchString(c.Name) {
+		return fmt.Errorf("currency name %q must be 1-16 characters of a-z, 0-9 or _", c.Name)
+	}
+	if c.Decimals < 0 || c.Decimals > MaxScale {
+		return fmt.Errorf("currency %s decimals must be between 0 and %d", c.Name, MaxScale)
+	}
+	if c.DefaultBalance < 0 {
+		return fmt.Errorf("currency %s default balance cannot be negative", c.Name)
+	}
+	return nil
+}
+
+// Parse parses a user supplied amount of this currency.
+func (c Currency) Parse(s string) (Money, error) {
+	return ParseMoney(s, c.Decimals)
+}
+
+// Format formats an amount of this currency for display, e.g. "$12.50" or "12.50 coins".
+func (c Currency) Format(m Money) string {
+	if c.Symbol != "" {
+		return c.Symbol + m.Format(c.Decimals)
+	}
+	return m.Format(c.Decimals) + " " + c.Name
+}
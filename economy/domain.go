@@ -1,9 +1,91 @@
 package economy
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type EconomyEntry struct {
 	UUID    uuid.UUID // Player's UUID
 	Name    string    // Display name
-	Balance float64   // Balance
+	Balance Money     // Balance
+}
+
+// TransactionType identifies the kind of balance mutation recorded in the ledger.
+type TransactionType string
+
+const (
+	TransactionRegister TransactionType = "register" // Initial balance on registration
+	TransactionSet      TransactionType = "set"      // Balance overwritten by an admin
+	TransactionTransfer TransactionType = "transfer" // Payment between two players
+	TransactionExchange TransactionType = "exchange" // One leg of a currency conversion
+	TransactionFee      TransactionType = "fee"      // Transfer fee, burned or paid to the treasury
+	TransactionGive     TransactionType = "give"     // Amount added by an admin
+	TransactionTake     TransactionType = "take"     // Amount removed by an admin
+	TransactionBatch    TransactionType = "batch"    // Part of a multi-leg batch
+	TransactionOpening  TransactionType = "opening"  // Balance carried over from a version without a ledger
+)
+
+type Transaction struct {
+	ID             uint            // Ledger entry ID
+	Type           TransactionType // Kind of mutation
+	Currency       string          // Currency name
+	From           uuid.UUID       // Sender, uuid.Nil when money enters the economy
+	FromName       string          // Sender display name, empty when unknown
+	To             uuid.UUID       // Receiver
+	ToName         string          // Receiver display name, empty when unknown
+	Amount         Money           // Amount moved, or the balance delta for set
+	FromBalance    Money           // Sender balance after the transaction
+	ToBalance      Money           // Receiver balance after the transaction
+	Actor          uuid.UUID       // Who initiated the mutation, uuid.Nil for the system
+	ActorName      string          // Client that initiated the mutation, e.g. an API key, may be empty
+	Reason         string          // Audit reason given by the actor, may be empty
+	IdempotencyKey string          // Key of the request that wrote the entry, may be empty
+	CreatedAt      time.Time       // When the mutation was committed
+}
+
+// BalanceAudit compares a stored balance with the ledger entries recorded for it.
+type BalanceAudit struct {
+	Account  uuid.UUID   // Account UUID
+	Name     string      // Account display name, empty when the account is missing
+	Kind     AccountKind // Account kind, empty when the account is missing
+	Currency string      // Currency name
+	Balance  Money       // Stored balance
+	Ledger   Money       // Amount credited minus amount debited by the ledger
+}
+
+// TransferResult describes a completed transfer.
+type TransferResult struct {
+	Currency string        // Currency name
+	Amount   Money         // Amount debited from the sender
+	Fee      Money         // Part of the amount kept as fee
+	Received Money         // Amount credited to the receiver
+	Entries  []Transaction // Ledger entries written, the transfer followed by the fee if any
+}
+
+// Leg is one debit or credit of a batch. Negative amounts debit the account.
+type Leg struct {
+	Account  uuid.UUID // Account to debit or credit
+	Currency string    // Currency name, empty for the default currency
+	Amount   Money     // Amount credited, negative to debit
+}
+
+// Debit returns a leg taking amount from the account.
+func Debit(account uuid.UUID, currency string, amount Money) Leg {
+	return Leg{Account: account, Currency: currency, Amount: -amount}
+}
+
+// Credit returns a leg adding amount to the account.
+func Credit(account uuid.UUID, currency string, amount Money) Leg {
+	return Leg{Account: account, Currency: currency, Amount: amount}
+}
+
+// ExchangeQuote describes the outcome of converting between two currencies.
+type ExchangeQuote struct {
+	From     string // Currency that is paid
+	To       string // Currency that is received
+	Amount   Money  // Amount debited in From
+	Received Money  // Amount credited in To after the fee
+	Fee      Money  // Amount of To kept as fee
 }
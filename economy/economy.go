@@ -0,0 +1,58 @@
+package economy
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Economy is the stable API for plugins that read and change balances, e.g. a shop
+// charging players. Depend on it instead of the concrete service so the economy can
+// be swapped or mocked. An empty currency selects the default currency, errors match
+// the sentinel errors of this package with errors.Is.
+type Economy interface {
+	// Currency returns the currency with the given name
+	Currency(name string) (Currency, error)
+	// GetBalance returns the balance of the player
+	GetBalance(ctx context.Context, id uuid.UUID, currency string) (Money, error)
+	// Has reports whether the player has at least amount
+	Has(ctx context.Context, id uuid.UUID, currency string, amount Money) (bool, error)
+	// Withdraw removes amount from the player in a single conditional update. It fails
+	// with an *InsufficientFundsError if the balance cannot cover the amount
+	Withdraw(ctx context.Context, id uuid.UUID, currency string, amount Money) (BalanceResult, error)
+	// Deposit adds amount to the player
+	Deposit(ctx context.Context, id uuid.UUID, currency string, amount Money) (BalanceResult, error)
+	// TransferBalance moves amount between two players, charging the transfer fee
+	TransferBalance(ctx context.Context, fromID, toID uuid.UUID, currency string, amount Money) (TransferResult, error)
+	// FormatAmount formats amount with the symbol and decimals of the currency
+	FormatAmount(currency string, amount Money) string
+}
+
+// BalanceResult describes the outcome of a withdrawal or deposit.
+type BalanceResult struct {
+	Currency string      // Currency name
+	Amount   Money       // Amount withdrawn or deposited
+	Balance  Money       // Balance after the operation
+	Entry    Transaction // Ledger entry written
+}
+
+var (
+	providerMu sync.RWMutex
+	provider   Economy
+)
+
+// SetProvider makes e the Economy returned by Provider. The server calls it once the
+// economy is set up, nil removes the provider.
+func SetProvider(e Economy) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	provider = e
+}
+
+// Provider returns the Economy set by the server, ok is false if there is none.
+func Provider() (e Economy, ok bool) {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	return provider, provider != nil
+}
@@ -0,0 +1,38 @@
+package economy
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Errors returned by Economy implementations, match them with errors.Is.
+var (
+	ErrPlayerExists  = errors.New("player already exists")
+	ErrValidation    = errors.New("validation error")
+	ErrUnknownPlayer = errors.New("unknown player")
+	ErrInternalError = errors.New("internal error")
+	ErrCancelled     = errors.New("cancelled")
+	ErrUnknownBank   = errors.New("unknown bank")
+	ErrForbidden     = errors.New("forbidden")
+
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
+// InsufficientFundsError reports a balance that cannot cover a debit. It matches
+// ErrInsufficientFunds and ErrValidation.
+type InsufficientFundsError struct {
+	Account   uuid.UUID // Account that was debited
+	Currency  Currency  // Currency of the balance
+	Required  Money     // Amount that had to be debited
+	Available Money     // Balance when the debit was attempted
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("%v: %s required, %s available", ErrInsufficientFunds, e.Currency.Format(e.Required), e.Currency.Format(e.Available))
+}
+
+func (e *InsufficientFundsError) Unwrap() []error {
+	return []error{ErrInsufficientFunds, ErrValidation}
+}
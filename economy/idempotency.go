@@ -0,0 +1,21 @@
+package economy
+
+import "context"
+
+// MaxIdempotencyKeyLength is the maximum length of an idempotency key in bytes.
+const MaxIdempotencyKeyLength = 64
+
+type idempotencyKeyContext struct{}
+
+// WithIdempotencyKey returns a context carrying a client-supplied idempotency key.
+// A mutation called again with the same key returns the result of the first call
+// instead of applying twice, so clients can safely retry after timeouts.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContext{}, key)
+}
+
+// IdempotencyKey returns the idempotency key carried by ctx, empty if there is none.
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContext{}).(string)
+	return key
+}
@@ -0,0 +1,116 @@
+package economy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	DefaultScale = 2 // Default number of decimal places
+	MaxScale     = 8 // Maximum number of decimal places
+)
+
+var ErrInvalidMoney = errors.New("invalid money")
+
+// Money is an exact amount of currency stored as integer minor units.
+// The number of decimal places a minor unit represents is given by the scale,
+// e.g. Money(12345) with scale 2 is 123.45.
+type Money int64
+
+// ParseMoney parses a decimal string such as "123.45" into minor units.
+// Inputs with more decimal places than the scale are rejected rather than rounded.
+func ParseMoney(s string, scale int) (Money, error) {
+	if err := validateScale(scale); err != nil {
+		return 0, err
+	}
+	s = strings.TrimSpace(s)
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	whole, frac, hasDot := strings.Cut(s, ".")
+	if whole == "" && frac == "" || hasDot && frac == "" {
+		return 0, fmt.Errorf("%w: %q is not a number", ErrInvalidMoney, s)
+	}
+	if len(frac) > scale {
+		return 0, fmt.Errorf("%w: at most %d decimal places allowed", ErrInvalidMoney, scale)
+	}
+	frac += strings.Repeat("0", scale-len(frac))
+
+	var units int64
+	for _, r := range whole + frac {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("%w: %q is not a number", ErrInvalidMoney, s)
+		}
+		if units > (math.MaxInt64-int64(r-'0'))/10 {
+			return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, s)
+		}
+		units = units*10 + int64(r-'0')
+	}
+	if negative {
+		units = -units
+	}
+	return Money(units), nil
+}
+
+// MoneyFromFloat converts a float, e.g. from a config file, into minor units,
+// rounding half away from zero.
+func MoneyFromFloat(f float64, scale int) (Money, error) {
+	if err := validateScale(scale); err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("%w: must be a valid number", ErrInvalidMoney)
+	}
+	units := math.Round(f * math.Pow10(scale))
+	if units >= math.MaxInt64 || units <= math.MinInt64 {
+		return 0, fmt.Errorf("%w: %v is out of range", ErrInvalidMoney, f)
+	}
+	return Money(units), nil
+}
+
+// Format returns the amount as a decimal string with exactly scale decimal places.
+func (m Money) Format(scale int) string {
+	units := uint64(m)
+	sign := ""
+	if m < 0 {
+		units = uint64(-(m + 1)) + 1 // avoid overflow on math.MinInt64
+		sign = "-"
+	}
+	if scale <= 0 {
+		return fmt.Sprintf("%s%d", sign, units)
+	}
+	pow := uint64(math.Pow10(scale))
+	return fmt.Sprintf("%s%d.%0*d", sign, units/pow, scale, units%pow)
+}
+
+// Add returns m + o, reporting an error on overflow.
+func (m Money) Add(o Money) (Money, error) {
+	sum := m + o
+	if (o > 0 && sum < m) || (o < 0 && sum > m) {
+		return 0, fmt.Errorf("%w: addition overflows", ErrInvalidMoney)
+	}
+	return sum, nil
+}
+
+// Sub returns m - o, reporting an error on overflow.
+func (m Money) Sub(o Money) (Money, error) {
+	diff := m - o
+	if (o > 0 && diff > m) || (o < 0 && diff < m) {
+		return 0, fmt.Errorf("%w: subtraction overflows", ErrInvalidMoney)
+	}
+	return diff, nil
+}
+
+func validateScale(scale int) error {
+	if scale < 0 || scale > MaxScale {
+		return fmt.Errorf("%w: scale must be between 0 and %d", ErrInvalidMoney, MaxScale)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package economy
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// Balances are integer minor units, so NaN and infinities must be rejected before
+// they reach a db.DB.
+func TestMoneyFromFloatRejectsInvalid(t *testing.T) {
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 1e30, -1e30} {
+		if _, err := MoneyFromFloat(f, DefaultScale); !errors.Is(err, ErrInvalidMoney) {
+			t.Errorf("MoneyFromFloat(%v) error = %v, want %v", f, err, ErrInvalidMoney)
+		}
+	}
+}
+
+func TestMoneyFromFloat(t *testing.T) {
+	tests := []struct {
+		f     float64
+		scale int
+		want  Money
+	}{
+		{100, 2, 10000},
+		{1.005, 2, 100},
+		{-0.5, 0, -1},
+		{0.125, 2, 13},
+	}
+	for _, tt := range tests {
+		got, err := MoneyFromFloat(tt.f, tt.scale)
+		if err != nil || got != tt.want {
+			t.Errorf("MoneyFromFloat(%v, %d) = %d, %v, want %d", tt.f, tt.scale, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseMoneyRejectsInvalid(t *testing.T) {
+	for _, s := range []string{"NaN", "Inf", "-Inf", "+Inf", "1e3", "", ".", "1.", "1.234", "92233720368547758.08"} {
+		if _, err := ParseMoney(s, DefaultScale); !errors.Is(err, ErrInvalidMoney) {
+			t.Errorf("ParseMoney(%q) error = %v, want %v", s, err, ErrInvalidMoney)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/internal/db"
+)
+
+// CreateBank creates a shared bank account owned by the player with a zero balance.
+func (svc *EconomyService) CreateBank(ctx context.Context, owner uuid.UUID, name string) (economy.Bank, error) {
+	if err := economy.ValidateBankName(name); err != nil {
+		return economy.Bank{}, NewValidationError("bank", err.Error())
+	}
+	currencies := svc.settings().currencies
+	balances := make(map[string]economy.Money, len(currencies))
+	for _, c := range currencies {
+		balances[c.Name] = 0
+	}
+	id := uuid.New()
+	if _, err := svc.db.CreateBank(ctx, id, name, owner, balances); err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return economy.Bank{}, NewUnknownPlayerError(owner.String())
+		case errors.Is(err, db.ErrValidation):
+			return economy.Bank{}, NewValidationError("bank", err.Error())
+		default:
+			return economy.Bank{}, WrapInternalError("bank creation", err)
+		}
+	}
+	slog.Info("Bank created", "id", id, "name", name, "owner", owner)
+	return svc.Bank(ctx, name)
+}
+
+// Bank returns the bank with its members.
+func (svc *EconomyService) Bank(ctx context.Context, name string) (economy.Bank, error) {
+	bank, err := svc.db.Bank(ctx, name)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return economy.Bank{}, NewUnknownBankError(name)
+		case errors.Is(err, db.ErrValidation):
+			return economy.Bank{}, NewValidationError("bank", err.Error())
+		default:
+			return economy.Bank{}, WrapInternalError("bank query", err)
+		}
+	}
+	return bank, nil
+}
+
+// DepositBank moves money from the player into the bank. Requires the deposit role.
+func (svc *EconomyService) DepositBank(ctx context.Context, id uuid.UUID, name, currency string, amount economy.Money) (economy.TransferResult, error) {
+	bank, err := svc.Bank(ctx, name)
+	if err != nil {
+		return economy.TransferResult{}, err
+	}
+	return svc.transfer(ctx, id, bank.UUID, currency, amount, id, false, bankRole(bank.UUID, id, economy.BankRoleDeposit, "deposit to bank "+name))
+}
+
+// WithdrawBank moves money from the bank to the player. Requires the withdraw role.
+func (svc *EconomyService) WithdrawBank(ctx context.Context, id uuid.UUID, name, currency string, amount economy.Money) (economy.TransferResult, error) {
+	bank, err := svc.Bank(ctx, name)
+	if err != nil {
+		return economy.TransferResult{}, err
+	}
+	return svc.transfer(ctx, bank.UUID, id, currency, amount, id, false, bankRole(bank.UUID, id, economy.BankRoleWithdraw, "withdraw from bank "+name))
+}
+
+// bankRole requires member to have role in the bank when the transfer applies, so a
+// concurrent demotion takes effect before it. action describes the forbidden transfer.
+func bankRole(bank, member uuid.UUID, role economy.BankRole, action string) *bankAccess {
+	return &bankAccess{bank: bank, check: func(b economy.Bank) error {
+		if !b.Role(member).Allows(role) {
+			return NewForbiddenError(action)
+		}
+		return nil
+	}}
+}
+
+// SetBankMember adds a member to the bank or changes their role on behalf of actor.
+// Requires the manage role, only the owner may grant or revoke the manage role.
+func (svc *EconomyService) SetBankMember(ctx context.Context, actor uuid.UUID, name string, member uuid.UUID, role economy.BankRole) error {
+	if !role.Valid() || role == economy.BankRoleOwner {
+		return NewValidationError("role", "must be deposit, withdraw or manage")
+	}
+	bank, err := svc.Bank(ctx, name)
+	if err != nil {
+		return err
+	}
+	// Roles are checked against the members while the bank is locked, so a concurrent
+	// demotion of actor takes effect before the change
+	check := func(bank economy.Bank) error {
+		return checkBankManager(bank, actor, member, role)
+	}
+	if err := svc.db.SetBankMember(ctx, bank.UUID, member, role, check); err != nil {
+		switch {
+		case errors.Is(err, ErrForbidden):
+			return err
+		case errors.Is(err, db.ErrNotFound):
+			return NewUnknownPlayerError(member.String())
+		case errors.Is(err, db.ErrValidation):
+			return NewValidationError("bank member", err.Error())
+		default:
+			return WrapInternalError("bank member update", err)
+		}
+	}
+	return nil
+}
+
+// RemoveBankMember removes a member from the bank on behalf of actor. Members may
+// leave by removing themselves, except for the owner.
+func (svc *EconomyService) RemoveBankMember(ctx context.Context, actor uuid.UUID, name string, member uuid.UUID) error {
+	bank, err := svc.Bank(ctx, name)
+	if err != nil {
+		return err
+	}
+	// Roles are checked against the members while the bank is locked like in SetBankMember
+	check := func(bank economy.Bank) error {
+		current := bank.Role(member)
+		if current == "" {
+			return NewValidationError("member", "is not a member of bank "+name)
+		}
+		if actor != member || current == economy.BankRoleOwner {
+			return checkBankManager(bank, actor, member, current)
+		}
+		return nil
+	}
+	if err := svc.db.RemoveBankMember(ctx, bank.UUID, member, check); err != nil {
+		switch {
+		case errors.Is(err, ErrForbidden), errors.Is(err, ErrValidation):
+			return err
+		case errors.Is(err, db.ErrNotFound):
+			return NewValidationError("member", "is not a member of bank "+name)
+		default:
+			return WrapInternalError("bank member removal", err)
+		}
+	}
+	return nil
+}
+
+// checkBankManager checks that actor may change the membership of member, whose
+// old or new role is role.
+func checkBankManager(bank economy.Bank, actor, member uuid.UUID, role economy.BankRole) error {
+	actorRole := bank.Role(actor)
+	switch {
+	case !actorRole.Allows(economy.BankRoleManage):
+		return NewForbiddenError("manage members of bank " + bank.Name)
+	case actor == member:
+		return NewForbiddenError("change your own membership")
+	case bank.Role(member) == economy.BankRoleOwner:
+		return NewForbiddenError("change the owner of bank " + bank.Name)
+	case (role == economy.BankRoleManage || bank.Role(member) == economy.BankRoleManage) && actorRole != economy.BankRoleOwner:
+		return NewForbiddenError("change managers of bank " + bank.Name)
+	}
+	return nil
+}
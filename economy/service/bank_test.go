@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/config"
+	"github.com/skuralll/dfeconomy/economy/service"
+	"github.com/skuralll/dfeconomy/internal/db"
+)
+
+// demotingDB removes demoted from the bank right before every membership change and
+// bank transfer, like a concurrent command that commits after the service looked the
+// bank up.
+type demotingDB struct {
+	db.DB
+	demoted uuid.UUID
+}
+
+func (d demotingDB) SetBankMember(ctx context.Context, bankID, memberID uuid.UUID, role economy.BankRole, check func(economy.Bank) error) error {
+	if err := d.DB.RemoveBankMember(ctx, bankID, d.demoted, nil); err != nil {
+		return err
+	}
+	return d.DB.SetBankMember(ctx, bankID, memberID, role, check)
+}
+
+func (d demotingDB) RemoveBankMember(ctx context.Context, bankID, memberID uuid.UUID, check func(economy.Bank) error) error {
+	if err := d.DB.RemoveBankMember(ctx, bankID, d.demoted, nil); err != nil {
+		return err
+	}
+	return d.DB.RemoveBankMember(ctx, bankID, memberID, check)
+}
+
+func (d demotingDB) BankTransfer(ctx context.Context, bankID, fromID, toID uuid.UUID, currency string, amount economy.Money, check func(economy.Bank) error) ([]economy.Transaction, error) {
+	if err := d.DB.RemoveBankMember(ctx, bankID, d.demoted, nil); err != nil {
+		return nil, err
+	}
+	return d.DB.BankTransfer(ctx, bankID, fromID, toID, currency, amount, check)
+}
+
+// TestBankMemberConcurrentDemotion checks that membership changes are authorized
+// against the members at the time of the change.
+func TestBankMemberConcurrentDemotion(t *testing.T) {
+	ctx := context.Background()
+	owner, manager, member := uuid.New(), uuid.New(), uuid.New()
+	memory := service.NewMemoryDB()
+	svc, err := service.NewEconomyServiceWithDB(config.Config{}, nil, demotingDB{DB: memory, demoted: manager})
+	if err != nil {
+		t.Fatalf("NewEconomyServiceWithDB: %v", err)
+	}
+	for id, name := range map[uuid.UUID]string{owner: "owner", manager: "manager", member: "member"} {
+		if _, err := svc.RegisterUser(ctx, id, name); err != nil {
+			t.Fatalf("RegisterUser: %v", err)
+		}
+	}
+	bank, err := svc.CreateBank(ctx, owner, "guild")
+	if err != nil {
+		t.Fatalf("CreateBank: %v", err)
+	}
+	promote := func() {
+		t.Helper()
+		if err := memory.SetBankMember(ctx, bank.UUID, manager, economy.BankRoleManage, nil); err != nil {
+			t.Fatalf("SetBankMember: %v", err)
+		}
+	}
+
+	promote()
+	err = svc.SetBankMember(ctx, manager, "guild", member, economy.BankRoleDeposit)
+	assertForbidden(t, "SetBankMember", err)
+
+	if err := memory.SetBankMember(ctx, bank.UUID, member, economy.BankRoleDeposit, nil); err != nil {
+		t.Fatalf("SetBankMember: %v", err)
+	}
+	promote()
+	err = svc.RemoveBankMember(ctx, manager, "guild", member)
+	assertForbidden(t, "RemoveBankMember", err)
+
+	got, err := svc.Bank(ctx, "guild")
+	if err != nil {
+		t.Fatalf("Bank: %v", err)
+	}
+	if got.Role(member) != economy.BankRoleDeposit || got.Role(manager) != "" {
+		t.Errorf("members changed by a removed manager: %+v", got.Members)
+	}
+}
+
+// TestBankTransferConcurrentDemotion checks that deposits and withdrawals are
+// authorized against the members at the time of the transfer.
+func TestBankTransferConcurrentDemotion(t *testing.T) {
+	ctx := context.Background()
+	owner, member := uuid.New(), uuid.New()
+	memory := service.NewMemoryDB()
+	svc, err := service.NewEconomyServiceWithDB(config.Config{DefaultBalance: 100}, nil, demotingDB{DB: memory, demoted: member})
+	if err != nil {
+		t.Fatalf("NewEconomyServiceWithDB: %v", err)
+	}
+	for id, name := range map[uuid.UUID]string{owner: "owner", member: "member"} {
+		if _, err := svc.RegisterUser(ctx, id, name); err != nil {
+			t.Fatalf("RegisterUser: %v", err)
+		}
+	}
+	bank, err := svc.CreateBank(ctx, owner, "guild")
+	if err != nil {
+		t.Fatalf("CreateBank: %v", err)
+	}
+	if _, err := svc.TransferBalance(ctx, owner, bank.UUID, "", 5000); err != nil {
+		t.Fatalf("TransferBalance: %v", err)
+	}
+
+	for _, op := range []string{"WithdrawBank", "DepositBank"} {
+		if err := memory.SetBankMember(ctx, bank.UUID, member, economy.BankRoleWithdraw, nil); err != nil {
+			t.Fatalf("SetBankMember: %v", err)
+		}
+		transfer := svc.WithdrawBank
+		if op == "DepositBank" {
+			transfer = svc.DepositBank
+		}
+		_, err := transfer(ctx, member, "guild", "", 1000)
+		assertForbidden(t, op, err)
+	}
+
+	if balance, _ := svc.GetBalance(ctx, bank.UUID, ""); balance != 5000 {
+		t.Errorf("bank balance = %v, want 5000", balance)
+	}
+	if balance, _ := svc.GetBalance(ctx, member, ""); balance != 10000 {
+		t.Errorf("member balance = %v, want 10000", balance)
+	}
+}
+
+func assertForbidden(t *testing.T, op string, err error) {
+	t.Helper()
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("%s by a member removed concurrently: got %v, want %v", op, err, service.ErrForbidden)
+	}
+}
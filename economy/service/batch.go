@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/internal/db"
+)
+
+// Batch applies debits and credits of several accounts in one DB transaction on behalf
+// of actor, e.g. a trade where the buyer pays the seller while a middleman and the
+// treasury take a cut. The debits and credits of every currency must balance, and the
+// whole batch fails if any account is unknown or cannot cover its debits. Legs are
+// recorded as batch entries from debited to credited accounts with the reason.
+// A repeated idempotency key returns the entries of the first call without applying again.
+func (svc *EconomyService) Batch(ctx context.Context, legs []economy.Leg, actor uuid.UUID, reason string) ([]economy.Transaction, error) {
+	if entries, ok, err := svc.replay(ctx, db.OperationBatch); ok || err != nil {
+		return entries, err
+	}
+	resolved := make([]economy.Leg, 0, len(legs))
+	for _, leg := range legs {
+		c, err := svc.Currency(leg.Currency)
+		if err != nil {
+			return nil, err
+		}
+		leg.Currency = c.Name
+		resolved = append(resolved, leg)
+	}
+	entries, err := svc.db.Batch(ctx, resolved, actor, strings.TrimSpace(reason))
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrDuplicateKey):
+			return svc.replayClaimed(ctx, db.OperationBatch)
+		case errors.Is(err, db.ErrNotFound):
+			return nil, NewUnknownPlayerError("account in batch")
+		case errors.Is(err, db.ErrInsufficientBalance):
+			return nil, svc.insufficientFunds(err)
+		case errors.Is(err, db.ErrValidation):
+			return nil, NewValidationError("batch data", err.Error())
+		default:
+			return nil, WrapInternalError("batch", err)
+		}
+	}
+	svc.emitBalanceChanges(entries...)
+	return entries, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/config"
+)
+
+// newCurrencies builds the registered currencies from the configuration.
+func newCurrencies(cfg config.Config) ([]economy.Currency, error) {
+	list := cfg.CurrencyList()
+	currencies := make([]economy.Currency, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for _, cc := range list {
+		defaultBalance, err := economy.MoneyFromFloat(cc.DefaultBalance, cc.Decimals)
+		if err != nil {
+			return nil, NewValidationError("currency "+cc.Name+" default balance", err.Error())
+		}
+		c := economy.Currency{
+			Name:           cc.Name,
+			Symbol:         cc.Symbol,
+			Decimals:       cc.Decimals,
+			DefaultBalance: defaultBalance,
+		}
+		if err := c.Validate(); err != nil {
+			return nil, NewValidationError("currency", err.Error())
+		}
+		if seen[c.Name] {
+			return nil, NewValidationError("currency", "duplicate currency "+c.Name)
+		}
+		seen[c.Name] = true
+		currencies = append(currencies, c)
+	}
+	return currencies, nil
+}
+
+// Currency returns the registered currency by name, an empty name selects the default currency.
+func (svc *EconomyService) Currency(name string) (economy.Currency, error) {
+	return svc.settings().currency(name)
+}
+
+// Currencies returns all registered currencies, the first one is the default.
+func (svc *EconomyService) Currencies() []economy.Currency {
+	return append([]economy.Currency(nil), svc.settings().currencies...)
+}
+
+// ParseAmount parses a user supplied amount of the currency.
+func (svc *EconomyService) ParseAmount(currency, s string) (economy.Money, error) {
+	c, err := svc.Currency(currency)
+	if err != nil {
+		return 0, err
+	}
+	m, err := c.Parse(s)
+	if err != nil {
+		return 0, NewValidationError("amount", err.Error())
+	}
+	return m, nil
+}
+
+// FormatAmount formats an amount of the currency for display. Amounts of unknown
+// currencies, e.g. removed from the configuration, use the default scale.
+func (svc *EconomyService) FormatAmount(currency string, m economy.Money) string {
+	return svc.displayCurrency(currency).Format(m)
+}
+
+// displayCurrency returns the currency by name, falling back to the default scale
+// for unknown currencies.
+func (svc *EconomyService) displayCurrency(name string) economy.Currency {
+	c, err := svc.Currency(name)
+	if err != nil {
+		return economy.Currency{Name: name, Decimals: economy.DefaultScale}
+	}
+	return c
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+)
+
+// Has reports whether the player has at least amount of the currency.
+func (svc *EconomyService) Has(ctx context.Context, id uuid.UUID, currency string, amount economy.Money) (bool, error) {
+	if amount < 0 {
+		return false, NewValidationError("amount", "cannot be negative")
+	}
+	balance, err := svc.GetBalance(ctx, id, currency)
+	if err != nil {
+		return false, err
+	}
+	return balance >= amount, nil
+}
+
+// Withdraw removes amount from the player on behalf of the system, e.g. a purchase in
+// a shop plugin. It goes through TakeBalance, whose DB Adjust checks and debits the
+// balance in a single conditional update, so concurrent payments cannot overdraw it;
+// if it is too low Withdraw fails with an *economy.InsufficientFundsError.
+func (svc *EconomyService) Withdraw(ctx context.Context, id uuid.UUID, currency string, amount economy.Money) (economy.BalanceResult, error) {
+	entry, err := svc.TakeBalance(ctx, id, currency, amount, false, uuid.Nil, "")
+	if err != nil {
+		return economy.BalanceResult{}, err
+	}
+	return economy.BalanceResult{Currency: entry.Currency, Amount: entry.Amount, Balance: entry.FromBalance, Entry: entry}, nil
+}
+
+// Deposit adds amount to the player on behalf of the system.
+func (svc *EconomyService) Deposit(ctx context.Context, id uuid.UUID, currency string, amount economy.Money) (economy.BalanceResult, error) {
+	entry, err := svc.GiveBalance(ctx, id, currency, amount, uuid.Nil, "")
+	if err != nil {
+		return economy.BalanceResult{}, err
+	}
+	return economy.BalanceResult{Currency: entry.Currency, Amount: entry.Amount, Balance: entry.ToBalance, Entry: entry}, nil
+}
+
+// Implementation completeness checks
+var _ economy.Economy = (*EconomyService)(nil)
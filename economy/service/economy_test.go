@@ -0,0 +1,197 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/config"
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+// TestEconomyProvider uses the service only through the economy.Economy interface
+// like a third-party plugin would.
+func TestEconomyProvider(t *testing.T) {
+	svc, err := service.NewEconomyServiceWithDB(config.Config{DefaultBalance: 100}, nil, service.NewMemoryDB())
+	if err != nil {
+		t.Fatalf("NewEconomyServiceWithDB: %v", err)
+	}
+	ctx := context.Background()
+	id := uuid.New()
+	if _, err := svc.RegisterUser(ctx, id, "steve"); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	economy.SetProvider(svc)
+	t.Cleanup(func() { economy.SetProvider(nil) })
+
+	eco, ok := economy.Provider()
+	if !ok {
+		t.Fatal("Provider returned no economy")
+	}
+	if has, err := eco.Has(ctx, id, "", 10000); err != nil || !has {
+		t.Errorf("Has(100.00) = %v, %v, want true", has, err)
+	}
+	if has, err := eco.Has(ctx, id, "", 10001); err != nil || has {
+		t.Errorf("Has(100.01) = %v, %v, want false", has, err)
+	}
+
+	r, err := eco.Withdraw(ctx, id, "", 2500)
+	if err != nil {
+		t.Fatalf("Withdraw: %v", err)
+	}
+	if r.Amount != 2500 || r.Balance != 7500 || r.Entry.Type != economy.TransactionTake {
+		t.Errorf("Withdraw = %+v", r)
+	}
+	_, err = eco.Withdraw(ctx, id, "", 10000)
+	var insufficient *economy.InsufficientFundsError
+	if !errors.As(err, &insufficient) || !errors.Is(err, economy.ErrValidation) {
+		t.Fatalf("Withdraw over the balance: got %v, want *economy.InsufficientFundsError", err)
+	}
+	if insufficient.Required != 10000 || insufficient.Available != 7500 || insufficient.Currency.Name != "money" {
+		t.Errorf("Withdraw over the balance: got %+v", insufficient)
+	}
+	r, err = eco.Deposit(ctx, id, "", 500)
+	if err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+	if r.Amount != 500 || r.Balance != 8000 || r.Entry.Type != economy.TransactionGive {
+		t.Errorf("Deposit = %+v", r)
+	}
+	if _, err := eco.GetBalance(ctx, uuid.New(), ""); !errors.Is(err, economy.ErrUnknownPlayer) {
+		t.Errorf("GetBalance of an unknown player: got %v, want %v", err, economy.ErrUnknownPlayer)
+	}
+	if s := eco.FormatAmount("", 8000); s == "" {
+		t.Error("FormatAmount returned an empty string")
+	}
+
+	economy.SetProvider(nil)
+	if _, ok := economy.Provider(); ok {
+		t.Error("Provider returned an economy after it was removed")
+	}
+}
+
+func TestWithdrawConcurrentSQLite(t *testing.T) {
+	cfg := config.Config{DBType: "sqlite", DBDSN: filepath.Join(t.TempDir(), "economy.db"), DefaultBalance: 100}
+	svc, cleanup, err := service.NewEconomyService(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewEconomyService: %v", err)
+	}
+	t.Cleanup(cleanup)
+	withdrawConcurrent(t, svc)
+}
+
+func TestWithdrawConcurrentMemory(t *testing.T) {
+	svc, err := service.NewEconomyServiceWithDB(config.Config{DefaultBalance: 100}, nil, service.NewMemoryDB())
+	if err != nil {
+		t.Fatalf("NewEconomyServiceWithDB: %v", err)
+	}
+	withdrawConcurrent(t, svc)
+}
+
+// withdrawConcurrent withdraws more than the balance concurrently and checks that
+// only the withdrawals the balance covers succeed.
+func withdrawConcurrent(t *testing.T, svc *service.EconomyService) {
+	ctx := context.Background()
+	id := uuid.New()
+	if _, err := svc.RegisterUser(ctx, id, "steve"); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	var succeeded atomic.Int64
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.Withdraw(ctx, id, "", 3000)
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case !errors.Is(err, service.ErrInsufficientFunds):
+				t.Errorf("Withdraw: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := succeeded.Load(); n != 3 {
+		t.Errorf("%d withdrawals of 30.00 from 100.00 succeeded, want 3", n)
+	}
+	balance, err := svc.GetBalance(ctx, id, "")
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if balance != 1000 {
+		t.Errorf("balance = %d, want 1000", balance)
+	}
+}
+
+// TestInsufficientFunds checks that every debit reports the amount it needed and the
+// balance it found.
+func TestInsufficientFunds(t *testing.T) {
+	cfg := config.Config{
+		Currencies: []config.Currency{
+			{Name: "money", Symbol: "$", Decimals: 2, DefaultBalance: 100},
+			{Name: "gems", Decimals: 2},
+		},
+		ExchangeRates: []config.ExchangeRate{{From: "money", To: "gems", Rate: "1"}},
+		TransferFee:   config.TransferFee{Flat: "1.00"},
+	}
+	svc, err := service.NewEconomyServiceWithDB(cfg, nil, service.NewMemoryDB())
+	if err != nil {
+		t.Fatalf("NewEconomyServiceWithDB: %v", err)
+	}
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+	for id, name := range map[uuid.UUID]string{alice: "alice", bob: "bob"} {
+		if _, err := svc.RegisterUser(ctx, id, name); err != nil {
+			t.Fatalf("RegisterUser: %v", err)
+		}
+	}
+	tests := []struct {
+		name     string
+		debit    func() error
+		account  uuid.UUID
+		currency string
+		required economy.Money
+	}{
+		{"Withdraw", func() error {
+			_, err := svc.Withdraw(ctx, alice, "", 10001)
+			return err
+		}, alice, "money", 10001},
+		{"Withdraw missing balance", func() error {
+			_, err := svc.Withdraw(ctx, alice, "gems", 1)
+			return err
+		}, alice, "gems", 1},
+		{"TransferBalance", func() error {
+			_, err := svc.TransferBalance(ctx, alice, bob, "", 10001)
+			return err
+		}, alice, "money", 10001},
+		{"Exchange", func() error {
+			_, err := svc.Exchange(ctx, alice, "money", "gems", 10001)
+			return err
+		}, alice, "money", 10001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available, err := svc.GetBalance(ctx, tt.account, tt.currency)
+			if err != nil {
+				t.Fatalf("GetBalance: %v", err)
+			}
+			var insufficient *economy.InsufficientFundsError
+			if err := tt.debit(); !errors.As(err, &insufficient) {
+				t.Fatalf("got %v, want *economy.InsufficientFundsError", err)
+			}
+			if insufficient.Account != tt.account || insufficient.Currency.Name != tt.currency ||
+				insufficient.Required != tt.required || insufficient.Available != available {
+				t.Errorf("got %+v, want %s required %d, available %d", insufficient, tt.currency, tt.required, available)
+			}
+			if balance, err := svc.GetBalance(ctx, tt.account, tt.currency); err != nil || balance != available {
+				t.Errorf("balance after the failed debit = %d, %v, want %d", balance, err, available)
+			}
+		})
+	}
+}
@@ -3,13 +3,24 @@ package service
 import (
 	"errors"
 	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/internal/db"
 )
 
+// Sentinel errors of the service, shared with the economy package so plugins using
+// economy.Economy can match them without importing the service
 var (
-	ErrPlayerExists   = errors.New("player already exists")
-	ErrValidation     = errors.New("validation error")
-	ErrUnknownPlayer  = errors.New("unknown player")
-	ErrInternalError  = errors.New("internal error")
+	ErrPlayerExists  = economy.ErrPlayerExists
+	ErrValidation    = economy.ErrValidation
+	ErrUnknownPlayer = economy.ErrUnknownPlayer
+	ErrInternalError = economy.ErrInternalError
+	ErrCancelled     = economy.ErrCancelled
+	ErrUnknownBank   = economy.ErrUnknownBank
+	ErrForbidden     = economy.ErrForbidden
+
+	ErrInsufficientFunds = economy.ErrInsufficientFunds
 )
 
 func NewPlayerExistsError(id string) error {
@@ -24,6 +35,38 @@ func NewUnknownPlayerError(identifier string) error {
 	return fmt.Errorf("%w: %s", ErrUnknownPlayer, identifier)
 }
 
+func NewUnknownBankError(name string) error {
+	return fmt.Errorf("%w: %s", ErrUnknownBank, name)
+}
+
+func NewForbiddenError(action string) error {
+	return fmt.Errorf("%w: not allowed to %s", ErrForbidden, action)
+}
+
+func NewCancelledError(reason string) error {
+	return fmt.Errorf("%w: %s", ErrCancelled, reason)
+}
+
 func NewInternalError(operation, message string) error {
 	return fmt.Errorf("%w: %s failed: %s", ErrInternalError, operation, message)
 }
+
+// WrapInternalError creates a new internal error keeping the cause in the chain, so
+// callers can still match context.DeadlineExceeded and context.Canceled
+func WrapInternalError(operation string, err error) error {
+	return fmt.Errorf("%w: %s failed: %w", ErrInternalError, operation, err)
+}
+
+// NewInsufficientFundsError creates a new insufficient funds error of the account
+func NewInsufficientFundsError(id uuid.UUID, c economy.Currency, required, available economy.Money) error {
+	return &economy.InsufficientFundsError{Account: id, Currency: c, Required: required, Available: available}
+}
+
+// insufficientFunds converts an insufficient balance error of the DB layer
+func (svc *EconomyService) insufficientFunds(err error) error {
+	var e *db.InsufficientBalanceError
+	if !errors.As(err, &e) {
+		return NewValidationError("balance", "insufficient funds")
+	}
+	return NewInsufficientFundsError(e.Account, svc.displayCurrency(e.Currency), e.Required, e.Available)
+}
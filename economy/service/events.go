@@ -0,0 +1,178 @@
+package service
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+)
+
+// UserRegisterEvent is emitted after a new user was registered.
+type UserRegisterEvent struct {
+	UUID     uuid.UUID
+	Name     string
+	Balances map[string]economy.Money // Initial balance per currency
+}
+
+// BalanceChangeEvent is emitted for every account whose balance changed,
+// whatever operation caused it.
+type BalanceChangeEvent struct {
+	UUID     uuid.UUID
+	Currency string
+	Before   economy.Money
+	After    economy.Money
+	Cause    economy.TransactionType // Operation that changed the balance
+	Actor    uuid.UUID               // Who initiated the change, uuid.Nil for the system
+}
+
+// TransferEvent is emitted after a transfer between two players completed.
+type TransferEvent struct {
+	From       uuid.UUID
+	To         uuid.UUID
+	Currency   string
+	Amount     economy.Money // Amount received
+	Fee        economy.Money // Fee paid by the sender on top of Amount
+	FromBefore economy.Money
+	FromAfter  economy.Money
+	ToBefore   economy.Money
+	ToAfter    economy.Money
+	Actor      uuid.UUID
+}
+
+// BalanceSetEvent is emitted after a balance was overwritten.
+type BalanceSetEvent struct {
+	UUID     uuid.UUID
+	Currency string
+	Before   economy.Money
+	After    economy.Money
+	Actor    uuid.UUID
+}
+
+// EventContext is passed to pre-operation handlers, which may cancel the operation.
+type EventContext struct {
+	cancelled bool
+	reason    string
+}
+
+// Cancel cancels the operation. The reason is shown to the player who initiated it.
+func (ctx *EventContext) Cancel(reason string) {
+	ctx.cancelled = true
+	ctx.reason = reason
+}
+
+// Cancelled reports whether a handler cancelled the operation.
+func (ctx *EventContext) Cancelled() bool {
+	return ctx.cancelled
+}
+
+// Reason returns the reason passed to Cancel.
+func (ctx *EventContext) Reason() string {
+	return ctx.reason
+}
+
+// Handler handles events emitted by EconomyService.
+// HandlePre* methods run before the operation, in registration order, and may cancel
+// it or rewrite the amount. The remaining methods are delivered synchronously after
+// the DB transaction committed, so they should return quickly.
+// Embed NopHandler to implement only the methods of interest.
+type Handler interface {
+	HandlePreTransfer(ctx *EventContext, from, to uuid.UUID, currency string, amount *economy.Money)
+	HandlePreBalanceSet(ctx *EventContext, id uuid.UUID, currency string, amount *economy.Money, actor uuid.UUID)
+	HandleUserRegister(e UserRegisterEvent)
+	HandleBalanceChange(e BalanceChangeEvent)
+	HandleTransfer(e TransferEvent)
+	HandleBalanceSet(e BalanceSetEvent)
+}
+
+// NopHandler implements Handler without doing anything.
+type NopHandler struct{}
+
+func (NopHandler) HandlePreTransfer(*EventContext, uuid.UUID, uuid.UUID, string, *economy.Money)   {}
+func (NopHandler) HandlePreBalanceSet(*EventContext, uuid.UUID, string, *economy.Money, uuid.UUID) {}
+func (NopHandler) HandleUserRegister(UserRegisterEvent)                                            {}
+func (NopHandler) HandleBalanceChange(BalanceChangeEvent)                                          {}
+func (NopHandler) HandleTransfer(TransferEvent)                                                    {}
+func (NopHandler) HandleBalanceSet(BalanceSetEvent)                                                {}
+
+// handlers is the list of subscribed handlers in registration order.
+type handlers struct {
+	mu      sync.RWMutex
+	nextID  uint64
+	entries []handlerEntry
+}
+
+type handlerEntry struct {
+	id uint64
+	h  Handler
+}
+
+// Handle subscribes h to economy events. The returned function unsubscribes it.
+func (svc *EconomyService) Handle(h Handler) (unregister func()) {
+	svc.handlers.mu.Lock()
+	defer svc.handlers.mu.Unlock()
+	svc.handlers.nextID++
+	id := svc.handlers.nextID
+	svc.handlers.entries = append(svc.handlers.entries, handlerEntry{id, h})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			svc.handlers.mu.Lock()
+			defer svc.handlers.mu.Unlock()
+			for i, e := range svc.handlers.entries {
+				if e.id == id {
+					svc.handlers.entries = append(svc.handlers.entries[:i:i], svc.handlers.entries[i+1:]...)
+					return
+				}
+			}
+		})
+	}
+}
+
+// emit calls fn for every subscribed handler. A panicking handler is logged and
+// does not affect other handlers or the caller.
+func (svc *EconomyService) emit(fn func(h Handler)) {
+	svc.handlers.mu.RLock()
+	entries := svc.handlers.entries
+	svc.handlers.mu.RUnlock()
+	for _, e := range entries {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					slog.Error("economy event handler panicked", "panic", r)
+				}
+			}()
+			fn(e.h)
+		}()
+	}
+}
+
+// emitPre runs fn for every subscribed handler until one cancels the operation,
+// returning a cancelled error carrying its reason.
+func (svc *EconomyService) emitPre(fn func(ctx *EventContext, h Handler)) error {
+	ctx := &EventContext{}
+	svc.emit(func(h Handler) {
+		if !ctx.cancelled {
+			fn(ctx, h)
+		}
+	})
+	if ctx.cancelled {
+		return NewCancelledError(ctx.reason)
+	}
+	return nil
+}
+
+// emitBalanceChanges emits a BalanceChangeEvent for each account touched by the ledger entries.
+func (svc *EconomyService) emitBalanceChanges(entries ...economy.Transaction) {
+	for _, t := range entries {
+		if t.From != uuid.Nil {
+			e := BalanceChangeEvent{t.From, t.Currency, t.FromBalance + t.Amount, t.FromBalance, t.Type, t.Actor}
+			svc.emit(func(h Handler) { h.HandleBalanceChange(e) })
+		}
+		if t.To != uuid.Nil {
+			e := BalanceChangeEvent{t.To, t.Currency, t.ToBalance - t.Amount, t.ToBalance, t.Type, t.Actor}
+			svc.emit(func(h Handler) { h.HandleBalanceChange(e) })
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/config"
+	"github.com/skuralll/dfeconomy/internal/db"
+)
+
+type exchangePair struct {
+	from, to string
+}
+
+// exchangeRate is a validated conversion between two registered currencies.
+type exchangeRate struct {
+	rate *big.Rat // Units of To per unit of From
+	fee  *big.Rat // Fraction of the converted amount kept as fee
+}
+
+// newExchangeRates validates the configured rates against the registered currencies.
+func newExchangeRates(list []config.ExchangeRate, currencies []economy.Currency) (map[exchangePair]exchangeRate, error) {
+	known := make(map[string]bool, len(currencies))
+	for _, c := range currencies {
+		known[c.Name] = true
+	}
+	rates := make(map[exchangePair]exchangeRate, len(list))
+	for _, r := range list {
+		pair := exchangePair{r.From, r.To}
+		if !known[r.From] || !known[r.To] {
+			return nil, NewValidationError("exchange rate", "unknown currency in "+r.From+" -> "+r.To)
+		}
+		if r.From == r.To {
+			return nil, NewValidationError("exchange rate", "cannot convert "+r.From+" into itself")
+		}
+		if _, ok := rates[pair]; ok {
+			return nil, NewValidationError("exchange rate", "duplicate rate "+r.From+" -> "+r.To)
+		}
+		rate, ok := new(big.Rat).SetString(r.Rate)
+		if !ok || rate.Sign() <= 0 {
+			return nil, NewValidationError("exchange rate", "rate of "+r.From+" -> "+r.To+" must be a positive number")
+		}
+		fee := new(big.Rat)
+		if r.Fee != "" {
+			if _, ok := fee.SetString(r.Fee); !ok || fee.Sign() < 0 || fee.Cmp(big.NewRat(1, 1)) >= 0 {
+				return nil, NewValidationError("exchange rate", "fee of "+r.From+" -> "+r.To+" must be at least 0 and below 1")
+			}
+		}
+		rates[pair] = exchangeRate{rate, fee}
+	}
+	return rates, nil
+}
+
+// ReloadExchangeRates validates and replaces the exchange rates, e.g. after the
+// configuration file changed. The previous rates are kept on error.
+func (svc *EconomyService) ReloadExchangeRates(list []config.ExchangeRate) error {
+	return svc.updateSettings(func(s *settings) error {
+		rates, err := newExchangeRates(list, s.currencies)
+		if err != nil {
+			return err
+		}
+		s.rates = rates
+		s.cfg.ExchangeRates = list
+		return nil
+	})
+}
+
+// QuoteExchange calculates how much of the target currency amount of the source
+// currency converts into. Results are rounded down so conversions never create money.
+func (svc *EconomyService) QuoteExchange(from, to string, amount economy.Money) (economy.ExchangeQuote, error) {
+	s := svc.settings()
+	fc, err := s.currency(from)
+	if err != nil {
+		return economy.ExchangeQuote{}, err
+	}
+	tc, err := s.currency(to)
+	if err != nil {
+		return economy.ExchangeQuote{}, err
+	}
+	if amount <= 0 {
+		return economy.ExchangeQuote{}, NewValidationError("amount", "must be positive")
+	}
+	r, ok := s.rates[exchangePair{fc.Name, tc.Name}]
+	if !ok {
+		return economy.ExchangeQuote{}, NewValidationError("exchange", "cannot convert "+fc.Name+" into "+tc.Name)
+	}
+
+	// gross = amount * rate * 10^to.Decimals / 10^from.Decimals
+	gross := new(big.Rat).SetInt64(int64(amount))
+	gross.Mul(gross, r.rate)
+	gross.Mul(gross, new(big.Rat).SetInt(pow10(tc.Decimals)))
+	gross.Quo(gross, new(big.Rat).SetInt(pow10(fc.Decimals)))
+	net := new(big.Rat).Mul(gross, new(big.Rat).Sub(big.NewRat(1, 1), r.fee))
+
+	grossUnits := new(big.Int).Quo(gross.Num(), gross.Denom())
+	netUnits := new(big.Int).Quo(net.Num(), net.Denom())
+	if !grossUnits.IsInt64() {
+		return economy.ExchangeQuote{}, NewValidationError("amount", "too large to exchange")
+	}
+	if netUnits.Sign() <= 0 {
+		return economy.ExchangeQuote{}, NewValidationError("amount", "too small to exchange")
+	}
+	return economy.ExchangeQuote{
+		From:     fc.Name,
+		To:       tc.Name,
+		Amount:   amount,
+		Received: economy.Money(netUnits.Int64()),
+		Fee:      economy.Money(grossUnits.Int64() - netUnits.Int64()),
+	}, nil
+}
+
+// Exchange converts amount of one currency of the player into another at the
+// configured rate, debiting and crediting in a single DB transaction. A repeated
+// idempotency key returns the result of the first call without applying again.
+func (svc *EconomyService) Exchange(ctx context.Context, id uuid.UUID, from, to string, amount economy.Money) (economy.ExchangeQuote, error) {
+	quote, err := svc.QuoteExchange(from, to, amount)
+	if err != nil {
+		return economy.ExchangeQuote{}, err
+	}
+	if entries, ok, err := svc.replay(ctx, db.OperationExchange); ok || err != nil {
+		return exchangeQuote(quote, entries), err
+	}
+	entries, err := svc.db.Exchange(ctx, id, quote.From, quote.Amount, quote.To, quote.Received)
+	if err != nil {
+		if errors.Is(err, db.ErrDuplicateKey) {
+			entries, err := svc.replayClaimed(ctx, db.OperationExchange)
+			return exchangeQuote(quote, entries), err
+		}
+		if errors.Is(err, db.ErrNotFound) {
+			return economy.ExchangeQuote{}, NewUnknownPlayerError(id.String())
+		}
+		if errors.Is(err, db.ErrInsufficientBalance) {
+			return economy.ExchangeQuote{}, svc.insufficientFunds(err)
+		}
+		if errors.Is(err, db.ErrValidation) {
+			return economy.ExchangeQuote{}, NewValidationError("exchange data", err.Error())
+		}
+		return economy.ExchangeQuote{}, WrapInternalError("exchange", err)
+	}
+	svc.emitBalanceChanges(entries...)
+	return quote, nil
+}
+
+// exchangeQuote returns the quote of the exchange that wrote the ledger entries. The
+// fee is not recorded, it is taken from current when that converts the same amounts.
+func exchangeQuote(current economy.ExchangeQuote, entries []economy.Transaction) economy.ExchangeQuote {
+	if len(entries) != 2 {
+		return economy.ExchangeQuote{}
+	}
+	q := economy.ExchangeQuote{
+		From:     entries[0].Currency,
+		To:       entries[1].Currency,
+		Amount:   entries[0].Amount,
+		Received: entries[1].Amount,
+	}
+	if q.From == current.From && q.To == current.To && q.Amount == current.Amount && q.Received == current.Received {
+		q.Fee = current.Fee
+	}
+	return q
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
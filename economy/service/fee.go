@@ -0,0 +1,105 @@
+package service
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/config"
+)
+
+// FeeSinkBurn removes fees from circulation instead of crediting a system account.
+const FeeSinkBurn = "burn"
+
+// transferFee is the validated fee policy charged on transfers.
+type transferFee struct {
+	tiers []feeTier // Sorted by min ascending, a single zero tier without configured tiers
+	sink  uuid.UUID // Account credited with fees, uuid.Nil burns them
+}
+
+type feeTier struct {
+	min     string
+	percent *big.Rat
+	flat    string
+}
+
+// newTransferFee validates the fee policy. Amounts must be representable in every currency
+// and the sink must be one of the system accounts.
+func newTransferFee(cfg config.TransferFee, currencies []economy.Currency, system map[string]uuid.UUID) (transferFee, error) {
+	tiers := []config.FeeTier{{Percent: cfg.Percent, Flat: cfg.Flat}}
+	if len(cfg.Tiers) > 0 {
+		tiers = cfg.Tiers
+	}
+
+	var fee transferFee
+	for _, t := range tiers {
+		percent := new(big.Rat)
+		if t.Percent != "" {
+			if _, ok := percent.SetString(t.Percent); !ok || percent.Sign() < 0 || percent.Cmp(big.NewRat(1, 1)) >= 0 {
+				return transferFee{}, NewValidationError("transfer fee", "percent must be at least 0 and below 1")
+			}
+		}
+		for _, c := range currencies {
+			for _, amount := range []string{t.Min, t.Flat} {
+				if amount == "" {
+					continue
+				}
+				if m, err := c.Parse(amount); err != nil || m < 0 {
+					return transferFee{}, NewValidationError("transfer fee", "amount "+amount+" is invalid for currency "+c.Name)
+				}
+			}
+		}
+		fee.tiers = append(fee.tiers, feeTier{t.Min, percent, t.Flat})
+	}
+	sort.SliceStable(fee.tiers, func(i, j int) bool {
+		return compareDecimal(fee.tiers[i].min, fee.tiers[j].min) < 0
+	})
+
+	if cfg.Sink != "" && cfg.Sink != FeeSinkBurn {
+		sink, ok := system[cfg.Sink]
+		if !ok {
+			return transferFee{}, NewValidationError("transfer fee", "sink must be burn or a system account")
+		}
+		fee.sink = sink
+	}
+	return fee, nil
+}
+
+// calculate returns the fee for transferring amount of the currency, rounded down.
+func (f transferFee) calculate(c economy.Currency, amount economy.Money) economy.Money {
+	var tier feeTier
+	for _, t := range f.tiers {
+		min, _ := parseOrZero(c, t.min)
+		if amount < min {
+			break
+		}
+		tier = t
+	}
+	if tier.percent == nil {
+		return 0
+	}
+	flat, _ := parseOrZero(c, tier.flat)
+	percent := new(big.Rat).Mul(new(big.Rat).SetInt64(int64(amount)), tier.percent)
+	return flat + economy.Money(new(big.Int).Quo(percent.Num(), percent.Denom()).Int64())
+}
+
+// parseOrZero parses an optional configured amount, validated on startup.
+func parseOrZero(c economy.Currency, s string) (economy.Money, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return c.Parse(s)
+}
+
+// compareDecimal compares two validated decimal strings, empty meaning zero.
+func compareDecimal(a, b string) int {
+	ra, rb := new(big.Rat), new(big.Rat)
+	if a != "" {
+		ra.SetString(a)
+	}
+	if b != "" {
+		rb.SetString(b)
+	}
+	return ra.Cmp(rb)
+}
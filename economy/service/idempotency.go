@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/internal/db"
+)
+
+// replay returns the ledger entries written by the first call with the idempotency
+// key of ctx. ok is false when ctx carries no key or the key was not used yet.
+func (svc *EconomyService) replay(ctx context.Context, operation string) (entries []economy.Transaction, ok bool, err error) {
+	key := economy.IdempotencyKey(ctx)
+	if key == "" {
+		return nil, false, nil
+	}
+	op, entries, err := svc.db.Replay(ctx, key)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, WrapInternalError("idempotency key query", err)
+	case op != operation:
+		return nil, false, NewValidationError("idempotency key", "already used for another operation")
+	case len(entries) == 0:
+		return nil, false, NewInternalError("idempotency key query", "no ledger entries recorded")
+	}
+	return entries, true, nil
+}
+
+// replayClaimed returns the ledger entries of a concurrent call that claimed the
+// idempotency key of ctx first.
+func (svc *EconomyService) replayClaimed(ctx context.Context, operation string) ([]economy.Transaction, error) {
+	entries, ok, err := svc.replay(ctx, operation)
+	if err == nil && !ok {
+		return nil, NewInternalError("idempotency key query", "claimed key not found")
+	}
+	return entries, err
+}
+
+// firstEntry returns the first ledger entry, the zero entry if there is none.
+func firstEntry(entries []economy.Transaction) economy.Transaction {
+	if len(entries) == 0 {
+		return economy.Transaction{}
+	}
+	return entries[0]
+}
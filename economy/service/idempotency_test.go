@@ -0,0 +1,197 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/config"
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+func TestIdempotencySQLite(t *testing.T) {
+	cfg := stressConfig()
+	cfg.DBType = "sqlite"
+	cfg.DBDSN = filepath.Join(t.TempDir(), "economy.db")
+	svc, cleanup, err := service.NewEconomyService(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewEconomyService: %v", err)
+	}
+	t.Cleanup(cleanup)
+	testIdempotency(t, svc)
+}
+
+func TestIdempotencyMemory(t *testing.T) {
+	svc, err := service.NewEconomyServiceWithDB(stressConfig(), nil, service.NewMemoryDB())
+	if err != nil {
+		t.Fatalf("NewEconomyServiceWithDB: %v", err)
+	}
+	testIdempotency(t, svc)
+}
+
+// transferCounter counts transfer events.
+type transferCounter struct {
+	service.NopHandler
+	n atomic.Int64
+}
+
+func (c *transferCounter) HandleTransfer(service.TransferEvent) { c.n.Add(1) }
+
+// testIdempotency retries a keyed transfer concurrently and checks that it is applied
+// once and every call returns the same result.
+func testIdempotency(t *testing.T, svc *service.EconomyService) {
+	const retries = 8
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+	for id, name := range map[uuid.UUID]string{alice: "alice", bob: "bob"} {
+		if _, err := svc.RegisterUser(ctx, id, name); err != nil {
+			t.Fatalf("RegisterUser: %v", err)
+		}
+	}
+	counter := &transferCounter{}
+	svc.Handle(counter)
+
+	keyed := economy.WithIdempotencyKey(ctx, "order-1")
+	results := make([]economy.TransferResult, retries)
+	errs := make([]error, retries)
+	var wg sync.WaitGroup
+	for i := range retries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = svc.TransferBalance(keyed, alice, bob, "", 4000)
+		}()
+	}
+	wg.Wait()
+	for i := range retries {
+		if errs[i] != nil {
+			t.Fatalf("TransferBalance: %v", errs[i])
+		}
+		r := results[i]
+		if r.Amount != 4000 || r.Fee != 200 || r.Received != 3800 || len(r.Entries) != 2 ||
+			r.Entries[0].ID != results[0].Entries[0].ID {
+			t.Errorf("result %d = %+v, want the result of the first call %+v", i, r, results[0])
+		}
+	}
+	if n := counter.n.Load(); n != 1 {
+		t.Errorf("got %d transfer events, want 1", n)
+	}
+	balance, err := svc.GetBalance(ctx, alice, "")
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if balance != 6000 {
+		t.Errorf("alice balance = %d, want 6000", balance)
+	}
+
+	// A key cannot be reused for another operation
+	_, err = svc.GiveBalance(keyed, alice, "", 100, uuid.Nil, "")
+	if !errors.Is(err, service.ErrValidation) {
+		t.Errorf("GiveBalance with a transfer key: got %v, want %v", err, service.ErrValidation)
+	}
+	give := economy.WithIdempotencyKey(ctx, "give-1")
+	if _, err := svc.GiveBalance(give, alice, "", 100, uuid.Nil, ""); err != nil {
+		t.Fatalf("GiveBalance: %v", err)
+	}
+	_, err = svc.TakeBalance(give, alice, "", 100, false, uuid.Nil, "")
+	if !errors.Is(err, service.ErrValidation) {
+		t.Errorf("TakeBalance with a give key: got %v, want %v", err, service.ErrValidation)
+	}
+
+	// Set replays the first entry even if the amount differs
+	set := economy.WithIdempotencyKey(ctx, "set-1")
+	first, err := svc.SetBalance(set, bob, "bob", "", 500, uuid.Nil)
+	if err != nil {
+		t.Fatalf("SetBalance: %v", err)
+	}
+	again, err := svc.SetBalance(set, bob, "bob", "", 900, uuid.Nil)
+	if err != nil {
+		t.Fatalf("SetBalance: %v", err)
+	}
+	if again.ID != first.ID || again.ToBalance != 500 {
+		t.Errorf("replayed set entry %+v, want %+v", again, first)
+	}
+}
+
+func TestExchangeIdempotencySQLite(t *testing.T) {
+	cfg := exchangeConfig()
+	cfg.DBType = "sqlite"
+	cfg.DBDSN = filepath.Join(t.TempDir(), "economy.db")
+	svc, cleanup, err := service.NewEconomyService(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewEconomyService: %v", err)
+	}
+	t.Cleanup(cleanup)
+	testExchangeIdempotency(t, svc)
+}
+
+func TestExchangeIdempotencyMemory(t *testing.T) {
+	svc, err := service.NewEconomyServiceWithDB(exchangeConfig(), nil, service.NewMemoryDB())
+	if err != nil {
+		t.Fatalf("NewEconomyServiceWithDB: %v", err)
+	}
+	testExchangeIdempotency(t, svc)
+}
+
+// exchangeConfig converts money into gems keeping a fee.
+func exchangeConfig() config.Config {
+	return config.Config{
+		Currencies: []config.Currency{
+			{Name: "money", Symbol: "$", Decimals: 2, DefaultBalance: 100},
+			{Name: "gems", Decimals: 0},
+		},
+		ExchangeRates: []config.ExchangeRate{{From: "money", To: "gems", Rate: "1", Fee: "0.1"}},
+	}
+}
+
+// testExchangeIdempotency retries a keyed exchange concurrently and checks that it is
+// applied once and every call returns the same quote.
+func testExchangeIdempotency(t *testing.T, svc *service.EconomyService) {
+	const retries = 8
+	ctx := context.Background()
+	id := uuid.New()
+	if _, err := svc.RegisterUser(ctx, id, "steve"); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	want, err := svc.QuoteExchange("money", "gems", 5000)
+	if err != nil {
+		t.Fatalf("QuoteExchange: %v", err)
+	}
+
+	keyed := economy.WithIdempotencyKey(ctx, "exchange-1")
+	quotes := make([]economy.ExchangeQuote, retries)
+	errs := make([]error, retries)
+	var wg sync.WaitGroup
+	for i := range retries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			quotes[i], errs[i] = svc.Exchange(keyed, id, "money", "gems", 5000)
+		}()
+	}
+	wg.Wait()
+	for i := range retries {
+		if errs[i] != nil {
+			t.Fatalf("Exchange: %v", errs[i])
+		}
+		if quotes[i] != want {
+			t.Errorf("quote %d = %+v, want %+v", i, quotes[i], want)
+		}
+	}
+	for currency, balance := range map[string]economy.Money{"money": 5000, "gems": want.Received} {
+		if got, err := svc.GetBalance(ctx, id, currency); err != nil || got != balance {
+			t.Errorf("%s balance = %d, %v, want %d", currency, got, err, balance)
+		}
+	}
+
+	// A key cannot be reused for another operation
+	_, err = svc.GiveBalance(keyed, id, "", 100, uuid.Nil, "")
+	if !errors.Is(err, service.ErrValidation) {
+		t.Errorf("GiveBalance with an exchange key: got %v, want %v", err, service.ErrValidation)
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+
+	"github.com/skuralll/dfeconomy/economy"
+)
+
+// InvariantReport is the result of checking the balances against the ledger.
+type InvariantReport struct {
+	Balances   int                      // Number of balances checked
+	Supply     map[string]economy.Money // Sum of all balances per currency
+	Mismatched []economy.BalanceAudit   // Balances differing from the net amount of their ledger entries
+	Negative   []economy.BalanceAudit   // Balances below zero
+	Overflow   []string                 // Currencies whose supply exceeds the range of Money
+}
+
+// OK reports whether money is conserved and no balance is negative.
+func (r InvariantReport) OK() bool {
+	return r.Conserved() && r.NonNegative()
+}
+
+// Conserved reports whether every balance matches the ledger and no supply overflows.
+func (r InvariantReport) Conserved() bool {
+	return len(r.Mismatched) == 0 && len(r.Overflow) == 0
+}
+
+// NonNegative reports whether no balance is below zero. Only take force creates
+// negative balances.
+func (r InvariantReport) NonNegative() bool {
+	return len(r.Negative) == 0
+}
+
+// VerifyInvariants checks that money is neither created nor destroyed outside the
+// ledger: every balance must equal the amount the ledger credited minus the amount it
+// debited. The check reads the whole database and may be slow on large servers.
+func (svc *EconomyService) VerifyInvariants(ctx context.Context) (InvariantReport, error) {
+	audit, err := svc.db.Audit(ctx)
+	if err != nil {
+		return InvariantReport{}, WrapInternalError("invariant check", err)
+	}
+	report := InvariantReport{
+		Balances: len(audit),
+		Supply:   make(map[string]economy.Money),
+	}
+	overflow := make(map[string]bool)
+	for _, a := range audit {
+		if a.Balance != a.Ledger {
+			report.Mismatched = append(report.Mismatched, a)
+		}
+		if a.Balance < 0 {
+			report.Negative = append(report.Negative, a)
+		}
+		if overflow[a.Currency] {
+			continue
+		}
+		supply, err := report.Supply[a.Currency].Add(a.Balance)
+		if err != nil {
+			overflow[a.Currency] = true
+			report.Overflow = append(report.Overflow, a.Currency)
+			continue
+		}
+		report.Supply[a.Currency] = supply
+	}
+	return report, nil
+}
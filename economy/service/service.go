@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"maps"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 	"github.com/skuralll/df-permission/permission"
@@ -14,90 +18,308 @@ import (
 
 type EconomyService struct {
 	db         db.DB
-	cfg        config.Config
+	current    atomic.Pointer[settings] // Configuration snapshot, replaced on reload
+	reloadMu   sync.Mutex               // Serializes reloads
+	loader     func() (config.Config, error)
+	handlers   handlers
 	Permission permission.PermissionManager
 }
 
 // Get new EconomyService instance
 func NewEconomyService(cfg config.Config, pMgr permission.PermissionManager) (*EconomyService, func(), error) {
-	dbInstance, cleanup, err := db.NewDBGorm(cfg.DBType, cfg.DBDSN)
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, NewValidationError("config", err.Error())
+	}
+	s, err := newSettings(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	dbInstance, cleanup, err := db.NewDBGorm(cfg.DBType, cfg.DBDSN, s.currencies[0])
 	if err != nil {
 		return nil, nil, err
 	}
-	return &EconomyService{dbInstance, cfg, pMgr}, cleanup, nil
+	svc, err := NewEconomyServiceWithDB(cfg, pMgr, dbInstance)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return svc, cleanup, nil
+}
+
+// NewEconomyServiceWithDB returns an EconomyService storing its data in d, such as
+// the in-memory database of NewMemoryDB. The connection settings of cfg are ignored.
+func NewEconomyServiceWithDB(cfg config.Config, pMgr permission.PermissionManager, d db.DB) (*EconomyService, error) {
+	s, err := newSettings(cfg)
+	if err != nil {
+		return nil, err
+	}
+	svc := &EconomyService{
+		db:         d,
+		Permission: pMgr,
+	}
+	svc.current.Store(s)
+	if err := svc.ensureSystemAccounts(context.Background(), s); err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+// NewMemoryDB returns an empty database kept in memory, for tests and demos that
+// should not touch the disk.
+func NewMemoryDB() db.DB {
+	return db.NewDBMemory()
 }
 
 // Register a new user
 func (svc *EconomyService) RegisterUser(ctx context.Context, id uuid.UUID, name string) (bool, error) {
+	s := svc.settings()
 	// Check if user already exists
-	_, err := svc.db.Balance(ctx, id)
+	_, err := svc.db.Balance(ctx, id, s.currencies[0].Name)
 	if err == nil {
 		// User already exists
 		return false, NewPlayerExistsError(id.String())
 	}
-	// Register new user
-	err = svc.db.Set(ctx, id, name, svc.cfg.DefaultBalance)
+	// Register new user with the default balance of every currency
+	balances := make(map[string]economy.Money, len(s.currencies))
+	for _, c := range s.currencies {
+		balances[c.Name] = c.DefaultBalance
+	}
+	entries, err := svc.db.Register(ctx, id, name, economy.AccountPlayer, balances)
 	if err != nil {
 		if errors.Is(err, db.ErrValidation) {
 			return false, NewValidationError("user data", err.Error())
 		}
-		return false, NewInternalError("user registration", err.Error())
+		return false, WrapInternalError("user registration", err)
 	}
 	slog.Info("New user registered", "id", id, "name", name)
+	svc.emit(func(h Handler) { h.HandleUserRegister(UserRegisterEvent{id, name, maps.Clone(balances)}) })
+	svc.emitBalanceChanges(entries...)
 	return true, nil
 }
 
-// Get balance
-func (svc *EconomyService) GetBalance(ctx context.Context, id uuid.UUID) (float64, error) {
-	amount, err := svc.db.Balance(ctx, id)
+// Get balance, an empty currency selects the default currency
+func (svc *EconomyService) GetBalance(ctx context.Context, id uuid.UUID, currency string) (economy.Money, error) {
+	c, err := svc.Currency(currency)
+	if err != nil {
+		return 0, err
+	}
+	amount, err := svc.db.Balance(ctx, id, c.Name)
 	if err != nil {
 		if errors.Is(err, db.ErrNotFound) {
 			return 0, NewUnknownPlayerError(id.String())
 		}
-		return 0, NewInternalError("balance query", err.Error())
+		return 0, WrapInternalError("balance query", err)
 	}
 	return amount, nil
 }
 
-// Set balance
-func (svc *EconomyService) SetBalance(ctx context.Context, id uuid.UUID, name string, amount float64) error {
+// Set balance on behalf of actor. Pre-handlers may cancel the operation or rewrite the amount.
+// A repeated idempotency key returns the entry of the first call without applying again.
+func (svc *EconomyService) SetBalance(ctx context.Context, id uuid.UUID, name, currency string, amount economy.Money, actor uuid.UUID) (economy.Transaction, error) {
+	c, err := svc.Currency(currency)
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+	if entries, ok, err := svc.replay(ctx, db.OperationSet); ok || err != nil {
+		return firstEntry(entries), err
+	}
+	if err := svc.emitPre(func(ectx *EventContext, h Handler) { h.HandlePreBalanceSet(ectx, id, c.Name, &amount, actor) }); err != nil {
+		return economy.Transaction{}, err
+	}
 	if amount < 0 {
-		return NewValidationError("amount", "must be positive")
+		return economy.Transaction{}, NewValidationError("amount", "must be positive")
 	}
-	err := svc.db.Set(ctx, id, name, amount)
+	entry, err := svc.db.Set(ctx, id, name, c.Name, amount, actor)
 	if err != nil {
+		if errors.Is(err, db.ErrDuplicateKey) {
+			entries, err := svc.replayClaimed(ctx, db.OperationSet)
+			return firstEntry(entries), err
+		}
 		if errors.Is(err, db.ErrValidation) {
-			return NewValidationError("balance data", err.Error())
+			return economy.Transaction{}, NewValidationError("balance data", err.Error())
+		}
+		return economy.Transaction{}, WrapInternalError("balance update", err)
+	}
+	e := BalanceSetEvent{id, c.Name, entry.ToBalance - entry.Amount, entry.ToBalance, actor}
+	svc.emit(func(h Handler) { h.HandleBalanceSet(e) })
+	svc.emitBalanceChanges(entry)
+	return entry, nil
+}
+
+// GiveBalance adds amount to the balance on behalf of actor. The reason is kept in the ledger.
+func (svc *EconomyService) GiveBalance(ctx context.Context, id uuid.UUID, currency string, amount economy.Money, actor uuid.UUID, reason string) (economy.Transaction, error) {
+	if amount <= 0 {
+		return economy.Transaction{}, NewValidationError("amount", "must be positive")
+	}
+	return svc.adjustBalance(ctx, id, currency, amount, false, actor, reason)
+}
+
+// TakeBalance removes amount from the balance on behalf of actor. It fails when the
+// balance is insufficient unless force is set, in which case it may go negative.
+func (svc *EconomyService) TakeBalance(ctx context.Context, id uuid.UUID, currency string, amount economy.Money, force bool, actor uuid.UUID, reason string) (economy.Transaction, error) {
+	if amount <= 0 {
+		return economy.Transaction{}, NewValidationError("amount", "must be positive")
+	}
+	return svc.adjustBalance(ctx, id, currency, -amount, force, actor, reason)
+}
+
+// adjustBalance changes the balance by delta in a single conditional update. A
+// repeated idempotency key returns the entry of the first call without applying again.
+func (svc *EconomyService) adjustBalance(ctx context.Context, id uuid.UUID, currency string, delta economy.Money, allowNegative bool, actor uuid.UUID, reason string) (economy.Transaction, error) {
+	c, err := svc.Currency(currency)
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+	if entries, ok, err := svc.replay(ctx, db.OperationAdjust); ok || err != nil {
+		return replayedAdjustment(entries, delta, err)
+	}
+	entry, err := svc.db.Adjust(ctx, id, c.Name, delta, allowNegative, actor, strings.TrimSpace(reason))
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrDuplicateKey):
+			entries, err := svc.replayClaimed(ctx, db.OperationAdjust)
+			return replayedAdjustment(entries, delta, err)
+		case errors.Is(err, db.ErrNotFound):
+			return economy.Transaction{}, NewUnknownPlayerError(id.String())
+		case errors.Is(err, db.ErrInsufficientBalance):
+			return economy.Transaction{}, svc.insufficientFunds(err)
+		case errors.Is(err, db.ErrValidation):
+			return economy.Transaction{}, NewValidationError("balance data", err.Error())
+		default:
+			return economy.Transaction{}, WrapInternalError("balance update", err)
 		}
-		return NewInternalError("balance update", err.Error())
 	}
-	return nil
+	svc.emitBalanceChanges(entry)
+	return entry, nil
+}
+
+// replayedAdjustment returns the replayed entry of an adjustment, give and take
+// share an operation but must not replay each other.
+func replayedAdjustment(entries []economy.Transaction, delta economy.Money, err error) (economy.Transaction, error) {
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+	entry := firstEntry(entries)
+	if (delta > 0) != (entry.Type == economy.TransactionGive) {
+		return economy.Transaction{}, NewValidationError("idempotency key", "already used for another operation")
+	}
+	return entry, nil
+}
+
+// Transfer balance. Pre-handlers may cancel the transfer or rewrite the amount.
+// The configured transfer fee is deducted from the amount the receiver gets.
+func (svc *EconomyService) TransferBalance(ctx context.Context, fromID, toID uuid.UUID, currency string, amount economy.Money) (economy.TransferResult, error) {
+	return svc.TransferBalanceAs(ctx, fromID, toID, currency, amount, fromID)
 }
 
-// Transfer balance
-func (svc *EconomyService) TransferBalance(ctx context.Context, fromID, toID uuid.UUID, amount float64) error {
+// TransferBalanceAs transfers balance on behalf of actor, e.g. an admin moving money
+// out of a system account. Transfers from or to system accounts are free of fees.
+func (svc *EconomyService) TransferBalanceAs(ctx context.Context, fromID, toID uuid.UUID, currency string, amount economy.Money, actor uuid.UUID) (economy.TransferResult, error) {
+	chargeFee := !svc.IsSystemAccount(fromID) && !svc.IsSystemAccount(toID)
+	return svc.transfer(ctx, fromID, toID, currency, amount, actor, chargeFee, nil)
+}
+
+// bankAccess authorizes a transfer to or from a bank with the members of the bank at
+// the time of the transfer.
+type bankAccess struct {
+	bank  uuid.UUID
+	check func(economy.Bank) error
+}
+
+// transfer moves balance between two accounts, charging the transfer fee if requested.
+// A repeated idempotency key returns the result of the first call without applying again.
+// Transfers with a bank pass its access, which the database checks with the bank locked.
+func (svc *EconomyService) transfer(ctx context.Context, fromID, toID uuid.UUID, currency string, amount economy.Money, actor uuid.UUID, chargeFee bool, access *bankAccess) (economy.TransferResult, error) {
+	s := svc.settings()
+	c, err := s.currency(currency)
+	if err != nil {
+		return economy.TransferResult{}, err
+	}
+	if entries, ok, err := svc.replay(ctx, db.OperationTransfer); ok || err != nil {
+		return transferResult(entries), err
+	}
 	if fromID == toID {
-		return NewValidationError("target", "cannot target yourself")
+		return economy.TransferResult{}, NewValidationError("target", "cannot target yourself")
+	}
+	if err := svc.emitPre(func(ectx *EventContext, h Handler) { h.HandlePreTransfer(ectx, fromID, toID, c.Name, &amount) }); err != nil {
+		return economy.TransferResult{}, err
+	}
+	var fee economy.Money
+	if chargeFee {
+		fee = s.fee.calculate(c, amount)
+	}
+	if amount > 0 && fee >= amount {
+		return economy.TransferResult{}, NewValidationError("amount", "too small to cover the fee of "+c.Format(fee))
+	}
+	var entries []economy.Transaction
+	if access != nil {
+		entries, err = svc.db.BankTransfer(ctx, access.bank, fromID, toID, c.Name, amount, access.check)
+	} else {
+		entries, err = svc.db.Transfer(ctx, fromID, toID, c.Name, amount, fee, s.fee.sink, actor)
 	}
-	err := svc.db.Transfer(ctx, fromID, toID, amount)
 	if err != nil {
+		if errors.Is(err, ErrForbidden) {
+			return economy.TransferResult{}, err
+		}
+		if errors.Is(err, db.ErrDuplicateKey) {
+			entries, err := svc.replayClaimed(ctx, db.OperationTransfer)
+			return transferResult(entries), err
+		}
 		if errors.Is(err, db.ErrNotFound) {
-			return NewUnknownPlayerError("player in transfer")
+			return economy.TransferResult{}, NewUnknownPlayerError("player in transfer")
 		}
 		if errors.Is(err, db.ErrInsufficientBalance) {
-			return NewValidationError("balance", "insufficient funds")
+			return economy.TransferResult{}, svc.insufficientFunds(err)
 		}
 		if errors.Is(err, db.ErrValidation) {
-			return NewValidationError("transfer data", err.Error())
+			return economy.TransferResult{}, NewValidationError("transfer data", err.Error())
 		}
-		return NewInternalError("transfer", err.Error())
+		return economy.TransferResult{}, WrapInternalError("transfer", err)
+	}
+	last := entries[len(entries)-1]
+	e := TransferEvent{
+		From:       fromID,
+		To:         toID,
+		Currency:   c.Name,
+		Amount:     amount - fee,
+		Fee:        fee,
+		FromBefore: last.FromBalance + amount,
+		FromAfter:  last.FromBalance,
+		ToBefore:   entries[0].ToBalance - entries[0].Amount,
+		ToAfter:    entries[0].ToBalance,
+		Actor:      actor,
 	}
-	return nil
+	svc.emit(func(h Handler) { h.HandleTransfer(e) })
+	svc.emitBalanceChanges(entries...)
+	return transferResult(entries), nil
+}
+
+// transferResult summarizes the ledger entries written by a transfer.
+func transferResult(entries []economy.Transaction) economy.TransferResult {
+	if len(entries) == 0 {
+		return economy.TransferResult{}
+	}
+	r := economy.TransferResult{
+		Currency: entries[0].Currency,
+		Received: entries[0].Amount,
+		Entries:  entries,
+	}
+	for _, entry := range entries[1:] {
+		if entry.Type == economy.TransactionFee {
+			r.Fee += entry.Amount
+		}
+	}
+	r.Amount = r.Received + r.Fee
+	return r
 }
 
 // Get balance ranking
-func (svc *EconomyService) GetTopBalances(ctx context.Context, page, size int) ([]economy.EconomyEntry, error) {
+func (svc *EconomyService) GetTopBalances(ctx context.Context, currency string, page, size int) ([]economy.EconomyEntry, error) {
 	// validation
+	c, err := svc.Currency(currency)
+	if err != nil {
+		return nil, err
+	}
 	if size <= 0 {
 		return nil, NewValidationError("size", "must be at least 1")
 	}
@@ -105,13 +327,13 @@ func (svc *EconomyService) GetTopBalances(ctx context.Context, page, size int) (
 		return nil, NewValidationError("page", "must be at least 1")
 	}
 	// get result
-	list, err := svc.db.Top(ctx, page, size)
+	list, err := svc.db.Top(ctx, c.Name, page, size)
 	// error handle
 	if err != nil {
 		if errors.Is(err, db.ErrValidation) {
 			return nil, NewValidationError("pagination", err.Error())
 		}
-		return nil, NewInternalError("top query", err.Error())
+		return nil, WrapInternalError("top query", err)
 	}
 	if len(list) == 0 {
 		return nil, NewValidationError("page", "not found")
@@ -126,8 +348,44 @@ func (svc *EconomyService) GetUUIDByName(ctx context.Context, name string) (uuid
 		if errors.Is(err, db.ErrNotFound) {
 			return uuid.Nil, NewUnknownPlayerError(name)
 		}
-		return uuid.Nil, NewInternalError("player lookup", err.Error())
+		return uuid.Nil, WrapInternalError("player lookup", err)
 	}
 
 	return uid, nil
 }
+
+// AccountKind returns whether the account is a player, bank or system account, e.g.
+// to keep API clients that address accounts by UUID to player accounts.
+func (svc *EconomyService) AccountKind(ctx context.Context, id uuid.UUID) (economy.AccountKind, error) {
+	kind, err := svc.db.AccountKind(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return "", NewUnknownPlayerError(id.String())
+		}
+		return "", WrapInternalError("account lookup", err)
+	}
+	return kind, nil
+}
+
+// GetHistory returns a page of ledger entries involving the player, newest first.
+// uuid.Nil returns the history of every account, a non-nil counterparty limits the
+// result to entries between both players.
+func (svc *EconomyService) GetHistory(ctx context.Context, id, counterparty uuid.UUID, page, size int) ([]economy.Transaction, error) {
+	// validation
+	if size <= 0 {
+		return nil, NewValidationError("size", "must be at least 1")
+	}
+	if page <= 0 {
+		return nil, NewValidationError("page", "must be at least 1")
+	}
+	// get result
+	list, err := svc.db.History(ctx, id, counterparty, page, size)
+	// error handle
+	if err != nil {
+		if errors.Is(err, db.ErrValidation) {
+			return nil, NewValidationError("history query", err.Error())
+		}
+		return nil, WrapInternalError("history query", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/config"
+)
+
+// settings is an immutable snapshot of the configuration and everything derived
+// from it. Reloading swaps the whole snapshot, so an operation reading it once sees
+// a consistent configuration.
+type settings struct {
+	cfg        config.Config
+	currencies []economy.Currency // Registered currencies, the first one is the default
+	rates      map[exchangePair]exchangeRate
+	fee        transferFee
+	system     map[string]uuid.UUID // Reserved UUID of every system account by name
+}
+
+// newSettings validates the configuration and derives the snapshot from it.
+func newSettings(cfg config.Config) (*settings, error) {
+	if err := cfg.ValidateSettings(); err != nil {
+		return nil, NewValidationError("config", err.Error())
+	}
+	currencies, err := newCurrencies(cfg)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := newExchangeRates(cfg.ExchangeRates, currencies)
+	if err != nil {
+		return nil, err
+	}
+	system, err := newSystemAccounts(cfg.SystemAccounts)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := newTransferFee(cfg.TransferFee, currencies, system)
+	if err != nil {
+		return nil, err
+	}
+	return &settings{
+		cfg:        cfg,
+		currencies: currencies,
+		rates:      rates,
+		fee:        fee,
+		system:     system,
+	}, nil
+}
+
+// currency returns the registered currency by name, an empty name selects the default currency.
+func (s *settings) currency(name string) (economy.Currency, error) {
+	if name == "" {
+		return s.currencies[0], nil
+	}
+	for _, c := range s.currencies {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	return economy.Currency{}, NewValidationError("currency", "unknown currency "+name)
+}
+
+// settings returns the current configuration snapshot.
+func (svc *EconomyService) settings() *settings {
+	return svc.current.Load()
+}
+
+// Config returns the current configuration.
+func (svc *EconomyService) Config() config.Config {
+	return svc.settings().cfg
+}
+
+// SetConfigLoader sets the function ReloadConfig reads the configuration with,
+// e.g. one calling config.Load.
+func (svc *EconomyService) SetConfigLoader(load func() (config.Config, error)) {
+	svc.reloadMu.Lock()
+	defer svc.reloadMu.Unlock()
+	svc.loader = load
+}
+
+// ReloadConfig reads the configuration with the loader and applies it.
+func (svc *EconomyService) ReloadConfig(ctx context.Context) error {
+	svc.reloadMu.Lock()
+	load := svc.loader
+	svc.reloadMu.Unlock()
+	if load == nil {
+		return NewValidationError("reload", "no config loader is set")
+	}
+	cfg, err := load()
+	if err != nil {
+		return NewValidationError("config", err.Error())
+	}
+	return svc.Reload(ctx, cfg)
+}
+
+// Reload validates and applies a new configuration without restarting. The database
+// connection and the decimals of existing currencies cannot change at runtime. The
+// previous configuration is kept on error.
+func (svc *EconomyService) Reload(ctx context.Context, cfg config.Config) error {
+	next, err := newSettings(cfg)
+	if err != nil {
+		return err
+	}
+
+	svc.reloadMu.Lock()
+	defer svc.reloadMu.Unlock()
+	prev := svc.settings()
+	if cfg.DBType != prev.cfg.DBType || cfg.DBDSN != prev.cfg.DBDSN {
+		return NewValidationError("config", "db_type and db_dsn cannot change without a restart")
+	}
+	for _, c := range next.currencies {
+		if old, err := prev.currency(c.Name); err == nil && old.Decimals != c.Decimals {
+			return NewValidationError("config", "decimals of currency "+c.Name+" cannot change")
+		}
+	}
+	if err := svc.ensureSystemAccounts(ctx, next); err != nil {
+		return err
+	}
+	svc.current.Store(next)
+	slog.Info("Economy config reloaded")
+	return nil
+}
+
+// updateSettings applies fn to a copy of the current snapshot and stores the result.
+func (svc *EconomyService) updateSettings(fn func(s *settings) error) error {
+	svc.reloadMu.Lock()
+	defer svc.reloadMu.Unlock()
+	next := *svc.settings()
+	next.rates = maps.Clone(next.rates)
+	if err := fn(&next); err != nil {
+		return err
+	}
+	svc.current.Store(&next)
+	return nil
+}
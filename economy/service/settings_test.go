@@ -0,0 +1,117 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skuralll/dfeconomy/economy/config"
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+// reloadable returns a service whose config is loaded from a temporary file.
+func reloadable(t *testing.T, content string) (*service.EconomyService, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "economy.toml")
+	writeConfig(t, path, content)
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	svc, err := service.NewEconomyServiceWithDB(cfg, nil, service.NewMemoryDB())
+	if err != nil {
+		t.Fatalf("NewEconomyServiceWithDB: %v", err)
+	}
+	svc.SetConfigLoader(func() (config.Config, error) { return config.Load(path) })
+	return svc, path
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	ctx := context.Background()
+	svc, path := reloadable(t, "default_balance = 10.0\n")
+
+	writeConfig(t, path, "default_balance = 20.0\nenable_set_cmd = true\n")
+	if err := svc.ReloadConfig(ctx); err != nil {
+		t.Fatalf("ReloadConfig: %v", err)
+	}
+	if cfg := svc.Config(); cfg.DefaultBalance != 20 || !cfg.EnableSetCmd {
+		t.Errorf("Config after the reload = %+v", cfg)
+	}
+
+	invalid := []struct {
+		name    string
+		content string
+	}{
+		{"invalid toml", "default_balance = "},
+		{"invalid setting", "default_balance = -1.0\n"},
+		{"changed database", "db_dsn = \"other.db\"\ndefault_balance = 30.0\n"},
+		{"unknown fee sink", "default_balance = 30.0\n[transfer_fee]\nflat = \"1\"\nsink = \"nobody\"\n"},
+	}
+	for _, tt := range invalid {
+		writeConfig(t, path, tt.content)
+		if err := svc.ReloadConfig(ctx); !errors.Is(err, service.ErrValidation) {
+			t.Errorf("%s: ReloadConfig got %v, want %v", tt.name, err, service.ErrValidation)
+		}
+		if cfg := svc.Config(); cfg.DefaultBalance != 20 || !cfg.EnableSetCmd || cfg.TransferFee.Sink != "" {
+			t.Errorf("%s: previous config was not kept: %+v", tt.name, cfg)
+		}
+	}
+}
+
+// TestWatchReload wires config.Watch to Reload like a server does.
+func TestWatchReload(t *testing.T) {
+	svc, path := reloadable(t, "default_balance = 10.0\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 10)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		config.Watch(ctx, path, 10*time.Millisecond, func(cfg config.Config, err error) {
+			if err == nil {
+				err = svc.Reload(ctx, cfg)
+			}
+			errs <- err
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+	time.Sleep(50 * time.Millisecond)
+	next := func() error {
+		t.Helper()
+		select {
+		case err := <-errs:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("the config file change was not noticed")
+			return nil
+		}
+	}
+
+	writeConfig(t, path, "default_balance = 25.0\n")
+	if err := next(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := svc.Config().DefaultBalance; got != 25 {
+		t.Errorf("default_balance = %v after the reload, want 25", got)
+	}
+
+	writeConfig(t, path, "default_balance = \"lots\"\n")
+	if err := next(); err == nil {
+		t.Error("invalid config reported no error")
+	}
+	if got := svc.Config().DefaultBalance; got != 25 {
+		t.Errorf("default_balance = %v after an invalid config, want 25", got)
+	}
+}
@@ -0,0 +1,142 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/config"
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+func TestTransferStressSQLite(t *testing.T) {
+	cfg := stressConfig()
+	cfg.DBType = "sqlite"
+	cfg.DBDSN = filepath.Join(t.TempDir(), "economy.db")
+	svc, cleanup, err := service.NewEconomyService(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewEconomyService: %v", err)
+	}
+	t.Cleanup(cleanup)
+	stressTransfers(t, svc)
+}
+
+func TestTransferStressMemory(t *testing.T) {
+	svc, err := service.NewEconomyServiceWithDB(stressConfig(), nil, service.NewMemoryDB())
+	if err != nil {
+		t.Fatalf("NewEconomyServiceWithDB: %v", err)
+	}
+	stressTransfers(t, svc)
+}
+
+// stressConfig charges a fee paid to the treasury so fees are covered by the check.
+func stressConfig() config.Config {
+	return config.Config{
+		DefaultBalance: 100,
+		TransferFee:    config.TransferFee{Percent: "0.05", Sink: "treasury"},
+	}
+}
+
+// stressTransfers runs concurrent random transfers between many accounts and checks
+// that no money was created or destroyed and no balance went negative.
+func stressTransfers(t *testing.T, svc *service.EconomyService) {
+	const accounts, workers = 20, 16
+	transfers := 4000
+	if testing.Short() {
+		transfers = 400
+	}
+	ctx := context.Background()
+
+	ids := make([]uuid.UUID, accounts)
+	for i := range ids {
+		ids[i] = uuid.New()
+		if _, err := svc.RegisterUser(ctx, ids[i], "player"+string(rune('a'+i))); err != nil {
+			t.Fatalf("RegisterUser: %v", err)
+		}
+	}
+	before, err := svc.VerifyInvariants(ctx)
+	if err != nil {
+		t.Fatalf("VerifyInvariants: %v", err)
+	}
+
+	// Audits while transfers commit must still see a consistent snapshot
+	var audits atomic.Int64
+	stop := make(chan struct{})
+	verified := make(chan struct{})
+	go func() {
+		defer close(verified)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			report, err := svc.VerifyInvariants(ctx)
+			if err != nil {
+				t.Errorf("VerifyInvariants during transfers: %v", err)
+				return
+			}
+			if !report.Conserved() {
+				t.Errorf("balances differ from the ledger during transfers: %+v", report.Mismatched)
+				return
+			}
+			audits.Add(1)
+		}
+	}()
+
+	var succeeded, rejected, failed atomic.Int64
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(uint64(w), 1))
+			for range transfers / workers {
+				from, to := ids[rng.IntN(accounts)], ids[rng.IntN(accounts)]
+				amount := economy.Money(1 + rng.IntN(5000))
+				_, err := svc.TransferBalance(ctx, from, to, "", amount)
+				switch {
+				case err == nil:
+					succeeded.Add(1)
+				case errors.Is(err, service.ErrValidation):
+					// Same account, insufficient funds or an amount below the fee
+					rejected.Add(1)
+				default:
+					// Contention must be resolved by locking and retries
+					if failed.Add(1) == 1 {
+						t.Errorf("transfer failed: %v", err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-verified
+	t.Logf("%d transfers succeeded, %d rejected, %d failed, %d audits", succeeded.Load(), rejected.Load(), failed.Load(), audits.Load())
+	if succeeded.Load() == 0 {
+		t.Fatal("no transfer succeeded")
+	}
+
+	after, err := svc.VerifyInvariants(ctx)
+	if err != nil {
+		t.Fatalf("VerifyInvariants: %v", err)
+	}
+	if !after.Conserved() {
+		t.Errorf("balances differ from the ledger: %+v", after.Mismatched)
+	}
+	if !after.NonNegative() {
+		t.Errorf("negative balances: %+v", after.Negative)
+	}
+	for currency, supply := range before.Supply {
+		if after.Supply[currency] != supply {
+			t.Errorf("supply of %s changed from %d to %d", currency, supply, after.Supply[currency])
+		}
+	}
+}
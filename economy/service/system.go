@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/internal/db"
+)
+
+// newSystemAccounts returns the reserved UUID of every system account by name.
+// The treasury is always included.
+func newSystemAccounts(names []string) (map[string]uuid.UUID, error) {
+	system := map[string]uuid.UUID{economy.TreasuryAccountName: economy.SystemAccountID(economy.TreasuryAccountName)}
+	for _, name := range names {
+		if err := economy.ValidateSystemAccountName(name); err != nil {
+			return nil, NewValidationError("system account", err.Error())
+		}
+		system[name] = economy.SystemAccountID(name)
+	}
+	return system, nil
+}
+
+// ensureSystemAccounts creates the system accounts of the snapshot missing from the
+// database with a zero balance.
+func (svc *EconomyService) ensureSystemAccounts(ctx context.Context, s *settings) error {
+	for name, id := range s.system {
+		_, err := svc.db.Balance(ctx, id, s.currencies[0].Name)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, db.ErrNotFound) {
+			return WrapInternalError("system account query", err)
+		}
+		balances := make(map[string]economy.Money, len(s.currencies))
+		for _, c := range s.currencies {
+			balances[c.Name] = 0
+		}
+		if _, err := svc.db.Register(ctx, id, name, economy.AccountSystem, balances); err != nil {
+			return WrapInternalError("system account creation", err)
+		}
+		slog.Info("System account created", "id", id, "name", name)
+	}
+	return nil
+}
+
+// SystemAccounts returns the names of all system accounts in alphabetical order.
+func (svc *EconomyService) SystemAccounts() []string {
+	system := svc.settings().system
+	names := make([]string, 0, len(system))
+	for name := range system {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SystemAccount returns the reserved UUID of the system account.
+func (svc *EconomyService) SystemAccount(name string) (uuid.UUID, error) {
+	id, ok := svc.settings().system[name]
+	if !ok {
+		return uuid.Nil, NewValidationError("system account", "unknown account "+name)
+	}
+	return id, nil
+}
+
+// IsSystemAccount reports whether the UUID belongs to a system account.
+func (svc *EconomyService) IsSystemAccount(id uuid.UUID) bool {
+	for _, sid := range svc.settings().system {
+		if sid == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/config"
+	"github.com/skuralll/dfeconomy/grpcapi/economypb"
+)
+
+// AuthorizationMetadata is the request metadata carrying the API key as "Bearer <key>".
+const AuthorizationMetadata = "authorization"
+
+// KeySource returns the accepted API keys. It is called on every request, so keys
+// can be rotated with a config reload, e.g.
+// func() []config.APIKey { return svc.Config().HTTP.APIKeys }.
+type KeySource func() []config.APIKey
+
+// methodScopes maps every method to the scope its API key needs. An empty scope
+// only requires a valid key, methods missing here are denied.
+var methodScopes = map[string]string{
+	economypb.Economy_ListCurrencies_FullMethodName:  "",
+	economypb.Economy_GetBalance_FullMethodName:      config.ScopeBalanceRead,
+	economypb.Economy_GetUUIDByName_FullMethodName:   config.ScopeBalanceRead,
+	economypb.Economy_SetBalance_FullMethodName:      config.ScopeBalanceWrite,
+	economypb.Economy_Withdraw_FullMethodName:        config.ScopeBalanceWrite,
+	economypb.Economy_Deposit_FullMethodName:         config.ScopeBalanceWrite,
+	economypb.Economy_TransferBalance_FullMethodName: config.ScopeTransfer,
+	economypb.Economy_GetTopBalances_FullMethodName:  config.ScopeTopRead,
+}
+
+type apiKeyContext struct{}
+
+// UnaryServerInterceptor authenticates calls with the API keys of keys and checks the
+// scope of the method. Server rejects calls that did not pass it, so install it with
+// grpc.NewServer(grpc.UnaryInterceptor(grpcapi.UnaryServerInterceptor(keys))).
+func UnaryServerInterceptor(keys KeySource) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		key, ok := authenticate(ctx, keys())
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "missing or invalid API key")
+		}
+		scope, known := methodScopes[info.FullMethod]
+		if !known {
+			return nil, status.Errorf(codes.PermissionDenied, "method %s is not available", info.FullMethod)
+		}
+		if scope != "" && !key.Allows(scope) {
+			return nil, status.Errorf(codes.PermissionDenied, "API key lacks scope %s", scope)
+		}
+		return handler(context.WithValue(ctx, apiKeyContext{}, key), req)
+	}
+}
+
+// authenticate returns the configured API key sent in the request metadata.
+func authenticate(ctx context.Context, keys []config.APIKey) (config.APIKey, bool) {
+	values := metadata.ValueFromIncomingContext(ctx, AuthorizationMetadata)
+	if len(values) != 1 {
+		return config.APIKey{}, false
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return config.APIKey{}, false
+	}
+	return config.FindAPIKey(keys, token)
+}
+
+// incoming returns ctx attributing mutations to the API key that authenticated the
+// call and carrying the idempotency key sent in the request metadata. It fails when
+// UnaryServerInterceptor did not authenticate the call.
+func incoming(ctx context.Context) (context.Context, error) {
+	key, ok := ctx.Value(apiKeyContext{}).(config.APIKey)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "call was not authenticated, install grpcapi.UnaryServerInterceptor")
+	}
+	ctx = economy.WithActorName(ctx, key.Name)
+	if keys := metadata.ValueFromIncomingContext(ctx, IdempotencyKeyMetadata); len(keys) > 0 {
+		ctx = economy.WithIdempotencyKey(ctx, keys[0])
+	}
+	return ctx, nil
+}
+
+// apiKeyCredentials sends an API key with every call.
+type apiKeyCredentials string
+
+// APIKeyCredentials returns credentials sending key with every call, use them with
+// grpc.WithPerRPCCredentials. They work without transport security so economies on a
+// private network can talk over plain connections; use TLS everywhere else.
+func APIKeyCredentials(key string) credentials.PerRPCCredentials {
+	return apiKeyCredentials(key)
+}
+
+func (k apiKeyCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{AuthorizationMetadata: "Bearer " + string(k)}, nil
+}
+
+func (k apiKeyCredentials) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,211 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/grpcapi/economypb"
+)
+
+// Client uses the economy of another process over gRPC. It implements Service and
+// economy.Economy, so it can replace the local service, e.g. in a proxy. Errors match
+// the sentinel errors of the economy package like those of the local service.
+type Client struct {
+	rpc economypb.EconomyClient
+
+	mu         sync.RWMutex
+	currencies []economy.Currency // Currencies of the remote economy, the first one is the default
+}
+
+// NewClient returns a client calling the economy over conn and loads its currencies.
+// conn must send an API key of the remote economy, see APIKeyCredentials.
+func NewClient(ctx context.Context, conn grpc.ClientConnInterface) (*Client, error) {
+	c := &Client{rpc: economypb.NewEconomyClient(conn)}
+	if err := c.RefreshCurrencies(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// RefreshCurrencies reloads the currencies of the remote economy, e.g. after its
+// configuration was reloaded. Currency and FormatAmount use the loaded currencies.
+func (c *Client) RefreshCurrencies(ctx context.Context) error {
+	resp, err := c.rpc.ListCurrencies(ctx, &economypb.ListCurrenciesRequest{})
+	if err != nil {
+		return c.fromStatus(err)
+	}
+	currencies := make([]economy.Currency, 0, len(resp.GetCurrencies()))
+	for _, pb := range resp.GetCurrencies() {
+		currencies = append(currencies, fromCurrencyPB(pb))
+	}
+	if len(currencies) == 0 {
+		return fmt.Errorf("%w: remote economy has no currencies", economy.ErrInternalError)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.currencies = currencies
+	return nil
+}
+
+// Currencies returns the currencies of the remote economy, the first one is the default.
+func (c *Client) Currencies() []economy.Currency {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]economy.Currency(nil), c.currencies...)
+}
+
+// Currency returns the currency by name, an empty name selects the default currency.
+func (c *Client) Currency(name string) (economy.Currency, error) {
+	currencies := c.Currencies()
+	if name == "" {
+		return currencies[0], nil
+	}
+	for _, cur := range currencies {
+		if cur.Name == name {
+			return cur, nil
+		}
+	}
+	return economy.Currency{}, fmt.Errorf("%w: currency unknown currency %s", economy.ErrValidation, name)
+}
+
+// FormatAmount formats an amount of the currency for display. Amounts of unknown
+// currencies use the default scale.
+func (c *Client) FormatAmount(currency string, amount economy.Money) string {
+	return c.displayCurrency(currency).Format(amount)
+}
+
+// displayCurrency returns the currency by name, falling back to the default scale
+// for unknown currencies.
+func (c *Client) displayCurrency(name string) economy.Currency {
+	cur, err := c.Currency(name)
+	if err != nil {
+		return economy.Currency{Name: name, Decimals: economy.DefaultScale}
+	}
+	return cur
+}
+
+// outgoing returns ctx sending the idempotency key of ctx as request metadata.
+func outgoing(ctx context.Context) context.Context {
+	if key := economy.IdempotencyKey(ctx); key != "" {
+		return metadata.AppendToOutgoingContext(ctx, IdempotencyKeyMetadata, key)
+	}
+	return ctx
+}
+
+func (c *Client) GetBalance(ctx context.Context, id uuid.UUID, currency string) (economy.Money, error) {
+	resp, err := c.rpc.GetBalance(ctx, &economypb.GetBalanceRequest{Uuid: id.String(), Currency: currency})
+	if err != nil {
+		return 0, c.fromStatus(err)
+	}
+	return economy.Money(resp.GetBalance()), nil
+}
+
+// Has reports whether the player has at least amount of the currency.
+func (c *Client) Has(ctx context.Context, id uuid.UUID, currency string, amount economy.Money) (bool, error) {
+	if amount < 0 {
+		return false, fmt.Errorf("%w: amount cannot be negative", economy.ErrValidation)
+	}
+	balance, err := c.GetBalance(ctx, id, currency)
+	if err != nil {
+		return false, err
+	}
+	return balance >= amount, nil
+}
+
+// SetBalance overwrites the balance of the player. The remote economy records the API
+// key of the client as actor, actor is ignored.
+func (c *Client) SetBalance(ctx context.Context, id uuid.UUID, name, currency string, amount economy.Money, actor uuid.UUID) (economy.Transaction, error) {
+	resp, err := c.rpc.SetBalance(outgoing(ctx), &economypb.SetBalanceRequest{
+		Uuid:     id.String(),
+		Name:     name,
+		Currency: currency,
+		Amount:   int64(amount),
+	})
+	if err != nil {
+		return economy.Transaction{}, c.fromStatus(err)
+	}
+	return fromTransactionPB(resp.GetEntry())
+}
+
+func (c *Client) Withdraw(ctx context.Context, id uuid.UUID, currency string, amount economy.Money) (economy.BalanceResult, error) {
+	return c.changeBalance(ctx, id, currency, amount, c.rpc.Withdraw)
+}
+
+func (c *Client) Deposit(ctx context.Context, id uuid.UUID, currency string, amount economy.Money) (economy.BalanceResult, error) {
+	return c.changeBalance(ctx, id, currency, amount, c.rpc.Deposit)
+}
+
+// changeBalance calls Withdraw or Deposit of the remote economy.
+func (c *Client) changeBalance(ctx context.Context, id uuid.UUID, currency string, amount economy.Money, call func(ctx context.Context, req *economypb.ChangeBalanceRequest, opts ...grpc.CallOption) (*economypb.ChangeBalanceResponse, error)) (economy.BalanceResult, error) {
+	resp, err := call(outgoing(ctx), &economypb.ChangeBalanceRequest{Uuid: id.String(), Currency: currency, Amount: int64(amount)})
+	if err != nil {
+		return economy.BalanceResult{}, c.fromStatus(err)
+	}
+	entry, err := fromTransactionPB(resp.GetEntry())
+	if err != nil {
+		return economy.BalanceResult{}, err
+	}
+	return economy.BalanceResult{
+		Currency: resp.GetCurrency(),
+		Amount:   economy.Money(resp.GetAmount()),
+		Balance:  economy.Money(resp.GetBalance()),
+		Entry:    entry,
+	}, nil
+}
+
+func (c *Client) TransferBalance(ctx context.Context, fromID, toID uuid.UUID, currency string, amount economy.Money) (economy.TransferResult, error) {
+	resp, err := c.rpc.TransferBalance(outgoing(ctx), &economypb.TransferBalanceRequest{
+		From:     fromID.String(),
+		To:       toID.String(),
+		Currency: currency,
+		Amount:   int64(amount),
+	})
+	if err != nil {
+		return economy.TransferResult{}, c.fromStatus(err)
+	}
+	entries, err := fromTransactionsPB(resp.GetEntries())
+	if err != nil {
+		return economy.TransferResult{}, err
+	}
+	return economy.TransferResult{
+		Currency: resp.GetCurrency(),
+		Amount:   economy.Money(resp.GetAmount()),
+		Fee:      economy.Money(resp.GetFee()),
+		Received: economy.Money(resp.GetReceived()),
+		Entries:  entries,
+	}, nil
+}
+
+func (c *Client) GetTopBalances(ctx context.Context, currency string, page, size int) ([]economy.EconomyEntry, error) {
+	resp, err := c.rpc.GetTopBalances(ctx, &economypb.GetTopBalancesRequest{Currency: currency, Page: int32(page), Size: int32(size)})
+	if err != nil {
+		return nil, c.fromStatus(err)
+	}
+	list := make([]economy.EconomyEntry, 0, len(resp.GetEntries()))
+	for _, e := range resp.GetEntries() {
+		id, err := parseUUID("uuid", e.GetUuid())
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, economy.EconomyEntry{UUID: id, Name: e.GetName(), Balance: economy.Money(e.GetBalance())})
+	}
+	return list, nil
+}
+
+func (c *Client) GetUUIDByName(ctx context.Context, name string) (uuid.UUID, error) {
+	resp, err := c.rpc.GetUUIDByName(ctx, &economypb.GetUUIDByNameRequest{Name: name})
+	if err != nil {
+		return uuid.Nil, c.fromStatus(err)
+	}
+	return parseUUID("uuid", resp.GetUuid())
+}
+
+// Implementation completeness checks
+var _ Service = (*Client)(nil)
+var _ economy.Economy = (*Client)(nil)
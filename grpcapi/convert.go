@@ -0,0 +1,113 @@
+package grpcapi
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/grpcapi/economypb"
+)
+
+// parseUUID parses a UUID sent by the other side, an empty string is uuid.Nil.
+func parseUUID(field, s string) (uuid.UUID, error) {
+	if s == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %s must be a UUID", economy.ErrValidation, field)
+	}
+	return id, nil
+}
+
+// uuidString returns the UUID as string, empty for uuid.Nil.
+func uuidString(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+	return id.String()
+}
+
+func toCurrencyPB(c economy.Currency) *economypb.Currency {
+	return &economypb.Currency{
+		Name:           c.Name,
+		Symbol:         c.Symbol,
+		Decimals:       int32(c.Decimals),
+		DefaultBalance: int64(c.DefaultBalance),
+	}
+}
+
+func fromCurrencyPB(c *economypb.Currency) economy.Currency {
+	return economy.Currency{
+		Name:           c.GetName(),
+		Symbol:         c.GetSymbol(),
+		Decimals:       int(c.GetDecimals()),
+		DefaultBalance: economy.Money(c.GetDefaultBalance()),
+	}
+}
+
+func toTransactionPB(t economy.Transaction) *economypb.Transaction {
+	return &economypb.Transaction{
+		Id:             uint64(t.ID),
+		Type:           string(t.Type),
+		Currency:       t.Currency,
+		From:           uuidString(t.From),
+		FromName:       t.FromName,
+		To:             uuidString(t.To),
+		ToName:         t.ToName,
+		Amount:         int64(t.Amount),
+		FromBalance:    int64(t.FromBalance),
+		ToBalance:      int64(t.ToBalance),
+		Actor:          uuidString(t.Actor),
+		ActorName:      t.ActorName,
+		Reason:         t.Reason,
+		IdempotencyKey: t.IdempotencyKey,
+		CreatedAt:      timestamppb.New(t.CreatedAt),
+	}
+}
+
+func fromTransactionPB(t *economypb.Transaction) (economy.Transaction, error) {
+	from, err := parseUUID("from", t.GetFrom())
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+	to, err := parseUUID("to", t.GetTo())
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+	actor, err := parseUUID("actor", t.GetActor())
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+	return economy.Transaction{
+		ID:             uint(t.GetId()),
+		Type:           economy.TransactionType(t.GetType()),
+		Currency:       t.GetCurrency(),
+		From:           from,
+		FromName:       t.GetFromName(),
+		To:             to,
+		ToName:         t.GetToName(),
+		Amount:         economy.Money(t.GetAmount()),
+		FromBalance:    economy.Money(t.GetFromBalance()),
+		ToBalance:      economy.Money(t.GetToBalance()),
+		Actor:          actor,
+		ActorName:      t.GetActorName(),
+		Reason:         t.GetReason(),
+		IdempotencyKey: t.GetIdempotencyKey(),
+		CreatedAt:      t.GetCreatedAt().AsTime(),
+	}, nil
+}
+
+func fromTransactionsPB(list []*economypb.Transaction) ([]economy.Transaction, error) {
+	entries := make([]economy.Transaction, 0, len(list))
+	for _, t := range list {
+		entry, err := fromTransactionPB(t)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
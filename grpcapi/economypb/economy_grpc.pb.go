@@ -0,0 +1,417 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: economy.proto
+
+package economypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Economy_ListCurrencies_FullMethodName  = "/dfeconomy.v1.Economy/ListCurrencies"
+	Economy_GetBalance_FullMethodName      = "/dfeconomy.v1.Economy/GetBalance"
+	Economy_SetBalance_FullMethodName      = "/dfeconomy.v1.Economy/SetBalance"
+	Economy_Withdraw_FullMethodName        = "/dfeconomy.v1.Economy/Withdraw"
+	Economy_Deposit_FullMethodName         = "/dfeconomy.v1.Economy/Deposit"
+	Economy_TransferBalance_FullMethodName = "/dfeconomy.v1.Economy/TransferBalance"
+	Economy_GetTopBalances_FullMethodName  = "/dfeconomy.v1.Economy/GetTopBalances"
+	Economy_GetUUIDByName_FullMethodName   = "/dfeconomy.v1.Economy/GetUUIDByName"
+)
+
+// EconomyClient is the client API for Economy service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Economy mirrors the economy service for servers and proxies in other processes.
+// Amounts are integer minor units of their currency, UUIDs are canonical strings and
+// an empty currency selects the default currency. Calls send an API key of the
+// economy as "authorization: Bearer <key>" metadata and need the scope of the
+// method, mutations are recorded with the key name as actor. Mutations accept an
+// idempotency key in the "idempotency-key" request metadata.
+type EconomyClient interface {
+	// ListCurrencies returns the registered currencies, the first one is the default
+	ListCurrencies(ctx context.Context, in *ListCurrenciesRequest, opts ...grpc.CallOption) (*ListCurrenciesResponse, error)
+	// GetBalance returns the balance of a player
+	GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error)
+	// SetBalance overwrites the balance of a player
+	SetBalance(ctx context.Context, in *SetBalanceRequest, opts ...grpc.CallOption) (*SetBalanceResponse, error)
+	// Withdraw removes an amount from a player on behalf of the system
+	Withdraw(ctx context.Context, in *ChangeBalanceRequest, opts ...grpc.CallOption) (*ChangeBalanceResponse, error)
+	// Deposit adds an amount to a player on behalf of the system
+	Deposit(ctx context.Context, in *ChangeBalanceRequest, opts ...grpc.CallOption) (*ChangeBalanceResponse, error)
+	// TransferBalance moves an amount between two players, charging the transfer fee
+	TransferBalance(ctx context.Context, in *TransferBalanceRequest, opts ...grpc.CallOption) (*TransferBalanceResponse, error)
+	// GetTopBalances returns a page of the leaderboard
+	GetTopBalances(ctx context.Context, in *GetTopBalancesRequest, opts ...grpc.CallOption) (*GetTopBalancesResponse, error)
+	// GetUUIDByName returns the UUID of a player by name
+	GetUUIDByName(ctx context.Context, in *GetUUIDByNameRequest, opts ...grpc.CallOption) (*GetUUIDByNameResponse, error)
+}
+
+type economyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEconomyClient(cc grpc.ClientConnInterface) EconomyClient {
+	return &economyClient{cc}
+}
+
+func (c *economyClient) ListCurrencies(ctx context.Context, in *ListCurrenciesRequest, opts ...grpc.CallOption) (*ListCurrenciesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCurrenciesResponse)
+	err := c.cc.Invoke(ctx, Economy_ListCurrencies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *economyClient) GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBalanceResponse)
+	err := c.cc.Invoke(ctx, Economy_GetBalance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *economyClient) SetBalance(ctx context.Context, in *SetBalanceRequest, opts ...grpc.CallOption) (*SetBalanceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetBalanceResponse)
+	err := c.cc.Invoke(ctx, Economy_SetBalance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *economyClient) Withdraw(ctx context.Context, in *ChangeBalanceRequest, opts ...grpc.CallOption) (*ChangeBalanceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChangeBalanceResponse)
+	err := c.cc.Invoke(ctx, Economy_Withdraw_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *economyClient) Deposit(ctx context.Context, in *ChangeBalanceRequest, opts ...grpc.CallOption) (*ChangeBalanceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChangeBalanceResponse)
+	err := c.cc.Invoke(ctx, Economy_Deposit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *economyClient) TransferBalance(ctx context.Context, in *TransferBalanceRequest, opts ...grpc.CallOption) (*TransferBalanceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferBalanceResponse)
+	err := c.cc.Invoke(ctx, Economy_TransferBalance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *economyClient) GetTopBalances(ctx context.Context, in *GetTopBalancesRequest, opts ...grpc.CallOption) (*GetTopBalancesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTopBalancesResponse)
+	err := c.cc.Invoke(ctx, Economy_GetTopBalances_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *economyClient) GetUUIDByName(ctx context.Context, in *GetUUIDByNameRequest, opts ...grpc.CallOption) (*GetUUIDByNameResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUUIDByNameResponse)
+	err := c.cc.Invoke(ctx, Economy_GetUUIDByName_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EconomyServer is the server API for Economy service.
+// All implementations must embed UnimplementedEconomyServer
+// for forward compatibility.
+//
+// Economy mirrors the economy service for servers and proxies in other processes.
+// Amounts are integer minor units of their currency, UUIDs are canonical strings and
+// an empty currency selects the default currency. Calls send an API key of the
+// economy as "authorization: Bearer <key>" metadata and need the scope of the
+// method, mutations are recorded with the key name as actor. Mutations accept an
+// idempotency key in the "idempotency-key" request metadata.
+type EconomyServer interface {
+	// ListCurrencies returns the registered currencies, the first one is the default
+	ListCurrencies(context.Context, *ListCurrenciesRequest) (*ListCurrenciesResponse, error)
+	// GetBalance returns the balance of a player
+	GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error)
+	// SetBalance overwrites the balance of a player
+	SetBalance(context.Context, *SetBalanceRequest) (*SetBalanceResponse, error)
+	// Withdraw removes an amount from a player on behalf of the system
+	Withdraw(context.Context, *ChangeBalanceRequest) (*ChangeBalanceResponse, error)
+	// Deposit adds an amount to a player on behalf of the system
+	Deposit(context.Context, *ChangeBalanceRequest) (*ChangeBalanceResponse, error)
+	// TransferBalance moves an amount between two players, charging the transfer fee
+	TransferBalance(context.Context, *TransferBalanceRequest) (*TransferBalanceResponse, error)
+	// GetTopBalances returns a page of the leaderboard
+	GetTopBalances(context.Context, *GetTopBalancesRequest) (*GetTopBalancesResponse, error)
+	// GetUUIDByName returns the UUID of a player by name
+	GetUUIDByName(context.Context, *GetUUIDByNameRequest) (*GetUUIDByNameResponse, error)
+	mustEmbedUnimplementedEconomyServer()
+}
+
+// UnimplementedEconomyServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEconomyServer struct{}
+
+func (UnimplementedEconomyServer) ListCurrencies(context.Context, *ListCurrenciesRequest) (*ListCurrenciesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCurrencies not implemented")
+}
+func (UnimplementedEconomyServer) GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedEconomyServer) SetBalance(context.Context, *SetBalanceRequest) (*SetBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetBalance not implemented")
+}
+func (UnimplementedEconomyServer) Withdraw(context.Context, *ChangeBalanceRequest) (*ChangeBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Withdraw not implemented")
+}
+func (UnimplementedEconomyServer) Deposit(context.Context, *ChangeBalanceRequest) (*ChangeBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deposit not implemented")
+}
+func (UnimplementedEconomyServer) TransferBalance(context.Context, *TransferBalanceRequest) (*TransferBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferBalance not implemented")
+}
+func (UnimplementedEconomyServer) GetTopBalances(context.Context, *GetTopBalancesRequest) (*GetTopBalancesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTopBalances not implemented")
+}
+func (UnimplementedEconomyServer) GetUUIDByName(context.Context, *GetUUIDByNameRequest) (*GetUUIDByNameResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUUIDByName not implemented")
+}
+func (UnimplementedEconomyServer) mustEmbedUnimplementedEconomyServer() {}
+func (UnimplementedEconomyServer) testEmbeddedByValue()                 {}
+
+// UnsafeEconomyServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EconomyServer will
+// result in compilation errors.
+type UnsafeEconomyServer interface {
+	mustEmbedUnimplementedEconomyServer()
+}
+
+func RegisterEconomyServer(s grpc.ServiceRegistrar, srv EconomyServer) {
+	// If the following call pancis, it indicates UnimplementedEconomyServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Economy_ServiceDesc, srv)
+}
+
+func _Economy_ListCurrencies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCurrenciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EconomyServer).ListCurrencies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Economy_ListCurrencies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EconomyServer).ListCurrencies(ctx, req.(*ListCurrenciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Economy_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EconomyServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Economy_GetBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EconomyServer).GetBalance(ctx, req.(*GetBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Economy_SetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EconomyServer).SetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Economy_SetBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EconomyServer).SetBalance(ctx, req.(*SetBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Economy_Withdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangeBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EconomyServer).Withdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Economy_Withdraw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EconomyServer).Withdraw(ctx, req.(*ChangeBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Economy_Deposit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangeBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EconomyServer).Deposit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Economy_Deposit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EconomyServer).Deposit(ctx, req.(*ChangeBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Economy_TransferBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EconomyServer).TransferBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Economy_TransferBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EconomyServer).TransferBalance(ctx, req.(*TransferBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Economy_GetTopBalances_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTopBalancesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EconomyServer).GetTopBalances(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Economy_GetTopBalances_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EconomyServer).GetTopBalances(ctx, req.(*GetTopBalancesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Economy_GetUUIDByName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUUIDByNameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EconomyServer).GetUUIDByName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Economy_GetUUIDByName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EconomyServer).GetUUIDByName(ctx, req.(*GetUUIDByNameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Economy_ServiceDesc is the grpc.ServiceDesc for Economy service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Economy_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dfeconomy.v1.Economy",
+	HandlerType: (*EconomyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListCurrencies",
+			Handler:    _Economy_ListCurrencies_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _Economy_GetBalance_Handler,
+		},
+		{
+			MethodName: "SetBalance",
+			Handler:    _Economy_SetBalance_Handler,
+		},
+		{
+			MethodName: "Withdraw",
+			Handler:    _Economy_Withdraw_Handler,
+		},
+		{
+			MethodName: "Deposit",
+			Handler:    _Economy_Deposit_Handler,
+		},
+		{
+			MethodName: "TransferBalance",
+			Handler:    _Economy_TransferBalance_Handler,
+		},
+		{
+			MethodName: "GetTopBalances",
+			Handler:    _Economy_GetTopBalances_Handler,
+		},
+		{
+			MethodName: "GetUUIDByName",
+			Handler:    _Economy_GetUUIDByName_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "economy.proto",
+}
@@ -0,0 +1,9 @@
+// Package economypb holds the protobuf messages and gRPC stubs generated from
+// economy.proto.
+package economypb
+
+// The stubs are generated with protoc 29.3 and the plugin versions pinned by the tool
+// directives of go.mod. protoc writes its version into the stubs, so running
+// go generate followed by git diff --exit-code checks that they are up to date.
+//go:generate go build -o .bin/ google.golang.org/protobuf/cmd/protoc-gen-go google.golang.org/grpc/cmd/protoc-gen-go-grpc
+//go:generate protoc --plugin=.bin/protoc-gen-go --plugin=.bin/protoc-gen-go-grpc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative economy.proto
@@ -0,0 +1,136 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/skuralll/dfeconomy/economy"
+)
+
+// errorDomain is the domain of the errdetails.ErrorInfo attached to failed calls.
+const errorDomain = "dfeconomy"
+
+// errorKinds maps the sentinel errors of the economy package to gRPC codes and the
+// reasons sent in errdetails.ErrorInfo. Insufficient funds come first because they
+// also match ErrValidation.
+var errorKinds = []struct {
+	err    error
+	code   codes.Code
+	reason string
+}{
+	{economy.ErrInsufficientFunds, codes.FailedPrecondition, "INSUFFICIENT_FUNDS"},
+	{economy.ErrValidation, codes.InvalidArgument, "VALIDATION"},
+	{economy.ErrUnknownPlayer, codes.NotFound, "UNKNOWN_PLAYER"},
+	{economy.ErrUnknownBank, codes.NotFound, "UNKNOWN_BANK"},
+	{economy.ErrPlayerExists, codes.AlreadyExists, "PLAYER_EXISTS"},
+	{economy.ErrForbidden, codes.PermissionDenied, "FORBIDDEN"},
+	{economy.ErrCancelled, codes.Aborted, "CANCELLED"},
+	{economy.ErrInternalError, codes.Internal, "INTERNAL"},
+}
+
+// remoteError is an error returned by the remote economy. It keeps the message of
+// the remote error and matches its sentinel error.
+type remoteError struct {
+	msg string
+	err error
+}
+
+func (e *remoteError) Error() string { return e.msg }
+
+func (e *remoteError) Unwrap() error { return e.err }
+
+// internalMessage is sent instead of the message of internal errors, which may hold
+// database details.
+const internalMessage = "internal error"
+
+// toStatus converts an error of the economy into a gRPC status error that fromStatus
+// converts back. Internal errors are logged and sent without their message.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	for _, kind := range errorKinds {
+		if !errors.Is(err, kind.err) {
+			continue
+		}
+		msg := err.Error()
+		if kind.code == codes.Internal {
+			slog.Error("gRPC call failed", "error", err)
+			msg = internalMessage
+		}
+		info := &errdetails.ErrorInfo{Reason: kind.reason, Domain: errorDomain}
+		var insufficient *economy.InsufficientFundsError
+		if errors.As(err, &insufficient) {
+			info.Metadata = map[string]string{
+				"account":   insufficient.Account.String(),
+				"currency":  insufficient.Currency.Name,
+				"required":  strconv.FormatInt(int64(insufficient.Required), 10),
+				"available": strconv.FormatInt(int64(insufficient.Available), 10),
+			}
+		}
+		st, detailErr := status.New(kind.code, msg).WithDetails(info)
+		if detailErr != nil {
+			return status.Error(kind.code, msg)
+		}
+		return st.Err()
+	}
+	slog.Error("gRPC call failed", "error", err)
+	return status.Error(codes.Internal, internalMessage)
+}
+
+// fromStatus converts a gRPC status error into an error matching the sentinel errors
+// of the economy package. Rejected API keys match economy.ErrForbidden and transport
+// failures economy.ErrInternalError.
+func (c *Client) fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("%w: %v", economy.ErrInternalError, err)
+	}
+	switch st.Code() {
+	case codes.DeadlineExceeded:
+		return &remoteError{st.Message(), context.DeadlineExceeded}
+	case codes.Canceled:
+		return &remoteError{st.Message(), context.Canceled}
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return &remoteError{st.Message(), economy.ErrForbidden}
+	}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != errorDomain {
+			continue
+		}
+		if info.Reason == "INSUFFICIENT_FUNDS" {
+			return c.insufficientFunds(info.Metadata)
+		}
+		for _, kind := range errorKinds {
+			if kind.reason == info.Reason {
+				return &remoteError{st.Message(), kind.err}
+			}
+		}
+	}
+	return fmt.Errorf("%w: rpc failed with %s: %s", economy.ErrInternalError, st.Code(), st.Message())
+}
+
+// insufficientFunds rebuilds the *economy.InsufficientFundsError sent by the server.
+func (c *Client) insufficientFunds(meta map[string]string) error {
+	account, _ := uuid.Parse(meta["account"])
+	required, _ := strconv.ParseInt(meta["required"], 10, 64)
+	available, _ := strconv.ParseInt(meta["available"], 10, 64)
+	return &economy.InsufficientFundsError{
+		Account:   account,
+		Currency:  c.displayCurrency(meta["currency"]),
+		Required:  economy.Money(required),
+		Available: economy.Money(available),
+	}
+}
@@ -0,0 +1,313 @@
+package grpcapi_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/config"
+	"github.com/skuralll/dfeconomy/economy/service"
+	"github.com/skuralll/dfeconomy/grpcapi"
+	"github.com/skuralll/dfeconomy/grpcapi/economypb"
+	"github.com/skuralll/dfeconomy/internal/db"
+)
+
+const (
+	proxyKey  = "proxy-key-0123456789"
+	readerKey = "reader-key-0123456789"
+)
+
+// newRemote serves a new economy over an in-process listener and returns the local
+// service with a client connected to it using the proxy key.
+func newRemote(t *testing.T) (*service.EconomyService, *grpcapi.Client) {
+	t.Helper()
+	svc, ln := serve(t, true)
+	client, err := grpcapi.NewClient(context.Background(), dial(t, ln, proxyKey))
+	if err != nil {
+		t.Fatalf("grpcapi.NewClient: %v", err)
+	}
+	return svc, client
+}
+
+// serve serves a new economy accepting the proxy and reader keys over an in-process
+// listener, authenticating calls if auth is set.
+func serve(t *testing.T, auth bool) (*service.EconomyService, *bufconn.Listener) {
+	t.Helper()
+	return serveDB(t, auth, service.NewMemoryDB())
+}
+
+// serveDB is serve with the economy stored in d.
+func serveDB(t *testing.T, auth bool, d db.DB) (*service.EconomyService, *bufconn.Listener) {
+	t.Helper()
+	cfg := config.Config{
+		Currencies: []config.Currency{
+			{Name: "coins", Symbol: "$", Decimals: 2, DefaultBalance: 100},
+			{Name: "gems", Decimals: 0, DefaultBalance: 5},
+		},
+	}
+	cfg.HTTP.APIKeys = []config.APIKey{
+		{Name: "proxy", Key: proxyKey, Scopes: []string{"*"}},
+		{Name: "reader", Key: readerKey, Scopes: []string{"balance:read"}},
+	}
+	svc, err := service.NewEconomyServiceWithDB(cfg, nil, d)
+	if err != nil {
+		t.Fatalf("NewEconomyServiceWithDB: %v", err)
+	}
+
+	ln := bufconn.Listen(1 << 20)
+	var opts []grpc.ServerOption
+	if auth {
+		keys := func() []config.APIKey { return svc.Config().HTTP.APIKeys }
+		opts = append(opts, grpc.UnaryInterceptor(grpcapi.UnaryServerInterceptor(keys)))
+	}
+	srv := grpc.NewServer(opts...)
+	economypb.RegisterEconomyServer(srv, grpcapi.NewServer(svc))
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+	return svc, ln
+}
+
+// dial connects to ln sending key with every call, no key if it is empty.
+func dial(t *testing.T, ln *bufconn.Listener, key string) *grpc.ClientConn {
+	t.Helper()
+	opts := []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	if key != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(grpcapi.APIKeyCredentials(key)))
+	}
+	conn, err := grpc.NewClient("passthrough:///bufconn", opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestClient(t *testing.T) {
+	svc, client := newRemote(t)
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+	for id, name := range map[uuid.UUID]string{alice: "alice", bob: "bob"} {
+		if _, err := svc.RegisterUser(ctx, id, name); err != nil {
+			t.Fatalf("RegisterUser: %v", err)
+		}
+	}
+
+	// The client is used through the interface shared with the local service
+	var eco grpcapi.Service = client
+	if c, err := eco.Currency(""); err != nil || c.Name != "coins" || c.Symbol != "$" {
+		t.Errorf("Currency(\"\") = %+v, %v, want coins", c, err)
+	}
+	if got := eco.FormatAmount("coins", 1250); got != "$12.50" {
+		t.Errorf("FormatAmount = %q, want $12.50", got)
+	}
+	if id, err := eco.GetUUIDByName(ctx, "alice"); err != nil || id != alice {
+		t.Errorf("GetUUIDByName = %s, %v, want %s", id, err, alice)
+	}
+	if balance, err := eco.GetBalance(ctx, alice, "gems"); err != nil || balance != 5 {
+		t.Errorf("GetBalance(gems) = %d, %v, want 5", balance, err)
+	}
+	if has, err := eco.Has(ctx, alice, "", 10001); err != nil || has {
+		t.Errorf("Has(100.01) = %v, %v, want false", has, err)
+	}
+
+	transfer := economy.WithIdempotencyKey(ctx, "order-1")
+	for range 2 {
+		r, err := eco.TransferBalance(transfer, alice, bob, "", 2500)
+		if err != nil {
+			t.Fatalf("TransferBalance: %v", err)
+		}
+		if r.Currency != "coins" || r.Received != 2500 || len(r.Entries) != 1 || r.Entries[0].From != alice || r.Entries[0].IdempotencyKey != "order-1" {
+			t.Errorf("TransferBalance = %+v", r)
+		}
+	}
+	if balance, _ := svc.GetBalance(ctx, bob, ""); balance != 12500 {
+		t.Errorf("receiver balance after a retried transfer = %d, want 12500", balance)
+	}
+
+	r, err := eco.Withdraw(ctx, bob, "", 500)
+	if err != nil || r.Balance != 12000 || r.Entry.Type != economy.TransactionTake || r.Entry.Actor != uuid.Nil || r.Entry.ActorName != "proxy" {
+		t.Errorf("Withdraw = %+v, %v", r, err)
+	}
+	if r, err := eco.Deposit(ctx, bob, "gems", 3); err != nil || r.Balance != 8 {
+		t.Errorf("Deposit = %+v, %v", r, err)
+	}
+	// The actor sent by the client is not trusted, the entry records the API key
+	entry, err := eco.SetBalance(ctx, alice, "alice", "", 30000, bob)
+	if err != nil || entry.ToBalance != 30000 || entry.Actor != uuid.Nil || entry.ActorName != "proxy" || entry.CreatedAt.IsZero() {
+		t.Errorf("SetBalance = %+v, %v", entry, err)
+	}
+	top, err := eco.GetTopBalances(ctx, "", 1, 2)
+	if err != nil || len(top) != 2 || top[0].UUID != alice || top[1].Balance != 12000 {
+		t.Errorf("GetTopBalances = %+v, %v", top, err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	svc, client := newRemote(t)
+	ctx := context.Background()
+	alice := uuid.New()
+	if _, err := svc.RegisterUser(ctx, alice, "alice"); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+
+	_, err := client.GetUUIDByName(ctx, "nobody")
+	if !errors.Is(err, economy.ErrUnknownPlayer) || err.Error() != "unknown player: nobody" {
+		t.Errorf("GetUUIDByName(nobody): got %v, want %v", err, economy.ErrUnknownPlayer)
+	}
+	if _, err := client.GetBalance(ctx, alice, "dollars"); !errors.Is(err, economy.ErrValidation) {
+		t.Errorf("GetBalance(dollars): got %v, want %v", err, economy.ErrValidation)
+	}
+	if _, err := client.GetTopBalances(ctx, "", 0, 10); !errors.Is(err, economy.ErrValidation) {
+		t.Errorf("GetTopBalances(page 0): got %v, want %v", err, economy.ErrValidation)
+	}
+	if _, err := client.TransferBalance(ctx, alice, uuid.Nil, "", 1); !errors.Is(err, economy.ErrValidation) {
+		t.Errorf("TransferBalance to uuid.Nil: got %v, want %v", err, economy.ErrValidation)
+	}
+
+	_, err = client.Withdraw(ctx, alice, "", 20000)
+	var insufficient *economy.InsufficientFundsError
+	if !errors.As(err, &insufficient) || !errors.Is(err, economy.ErrValidation) {
+		t.Fatalf("Withdraw over the balance: got %v, want *economy.InsufficientFundsError", err)
+	}
+	if insufficient.Account != alice || insufficient.Currency.Symbol != "$" || insufficient.Required != 20000 || insufficient.Available != 10000 {
+		t.Errorf("Withdraw over the balance: got %+v", insufficient)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := client.GetBalance(cancelled, alice, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetBalance with cancelled context: got %v, want %v", err, context.Canceled)
+	}
+}
+
+// TestNonPlayerAccounts checks that clients cannot move money of bank and system
+// accounts or rename them.
+func TestNonPlayerAccounts(t *testing.T) {
+	svc, client := newRemote(t)
+	ctx := context.Background()
+	alice := uuid.New()
+	if _, err := svc.RegisterUser(ctx, alice, "alice"); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	bank, err := svc.CreateBank(ctx, alice, "guild")
+	if err != nil {
+		t.Fatalf("CreateBank: %v", err)
+	}
+	if _, err := svc.DepositBank(ctx, alice, "guild", "", 5000); err != nil {
+		t.Fatalf("DepositBank: %v", err)
+	}
+	treasury, err := svc.SystemAccount(economy.TreasuryAccountName)
+	if err != nil {
+		t.Fatalf("SystemAccount: %v", err)
+	}
+
+	for _, id := range []uuid.UUID{bank.UUID, treasury} {
+		calls := map[string]func() error{
+			"TransferBalance from": func() error { _, err := client.TransferBalance(ctx, id, alice, "", 100); return err },
+			"TransferBalance to":   func() error { _, err := client.TransferBalance(ctx, alice, id, "", 100); return err },
+			"Withdraw":             func() error { _, err := client.Withdraw(ctx, id, "", 100); return err },
+			"Deposit":              func() error { _, err := client.Deposit(ctx, id, "", 100); return err },
+			"SetBalance":           func() error { _, err := client.SetBalance(ctx, id, "renamed", "", 0, uuid.Nil); return err },
+		}
+		for name, call := range calls {
+			if err := call(); !errors.Is(err, economy.ErrValidation) {
+				t.Errorf("%s %s: got %v, want %v", name, id, err, economy.ErrValidation)
+			}
+		}
+	}
+	if got, err := svc.Bank(ctx, "guild"); err != nil || got.UUID != bank.UUID {
+		t.Errorf("Bank(guild) = %+v, %v, want the bank under its name", got, err)
+	}
+	if balance, _ := svc.GetBalance(ctx, bank.UUID, ""); balance != 5000 {
+		t.Errorf("bank balance = %d, want 5000", balance)
+	}
+	if balance, _ := svc.GetBalance(ctx, alice, ""); balance != 5000 {
+		t.Errorf("player balance = %d, want 5000", balance)
+	}
+}
+
+// brokenDB fails leaderboard queries with an error revealing database details.
+type brokenDB struct {
+	db.DB
+}
+
+func (brokenDB) Top(ctx context.Context, currency string, page, size int) ([]economy.EconomyEntry, error) {
+	return nil, db.WrapDatabaseError("top query", errors.New(`relation "balances" does not exist`))
+}
+
+func TestInternalError(t *testing.T) {
+	_, ln := serveDB(t, true, brokenDB{service.NewMemoryDB()})
+	client, err := grpcapi.NewClient(context.Background(), dial(t, ln, proxyKey))
+	if err != nil {
+		t.Fatalf("grpcapi.NewClient: %v", err)
+	}
+
+	_, err = client.GetTopBalances(context.Background(), "", 1, 10)
+	if !errors.Is(err, economy.ErrInternalError) {
+		t.Fatalf("GetTopBalances: got %v, want %v", err, economy.ErrInternalError)
+	}
+	if strings.Contains(err.Error(), "balances") {
+		t.Errorf("GetTopBalances error reveals the database error: %v", err)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	svc, ln := serve(t, true)
+	ctx := context.Background()
+	alice := uuid.New()
+	if _, err := svc.RegisterUser(ctx, alice, "alice"); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+
+	// Calls without a known key are rejected before reaching the economy
+	for _, key := range []string{"", "unknown-key-0123456789"} {
+		rpc := economypb.NewEconomyClient(dial(t, ln, key))
+		_, err := rpc.GetBalance(ctx, &economypb.GetBalanceRequest{Uuid: alice.String()})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("GetBalance with key %q: got %v, want %s", key, err, codes.Unauthenticated)
+		}
+		if _, err := grpcapi.NewClient(ctx, dial(t, ln, key)); !errors.Is(err, economy.ErrForbidden) {
+			t.Errorf("NewClient with key %q: got %v, want %v", key, err, economy.ErrForbidden)
+		}
+	}
+
+	// Keys are limited to their scopes
+	reader, err := grpcapi.NewClient(ctx, dial(t, ln, readerKey))
+	if err != nil {
+		t.Fatalf("grpcapi.NewClient: %v", err)
+	}
+	if balance, err := reader.GetBalance(ctx, alice, ""); err != nil || balance != 10000 {
+		t.Errorf("GetBalance with balance:read = %d, %v, want 10000", balance, err)
+	}
+	if _, err := reader.Deposit(ctx, alice, "", 100); !errors.Is(err, economy.ErrForbidden) {
+		t.Errorf("Deposit without balance:write: got %v, want %v", err, economy.ErrForbidden)
+	}
+	if _, err := reader.SetBalance(ctx, alice, "alice", "", 0, uuid.Nil); !errors.Is(err, economy.ErrForbidden) {
+		t.Errorf("SetBalance without balance:write: got %v, want %v", err, economy.ErrForbidden)
+	}
+	if balance, _ := svc.GetBalance(ctx, alice, ""); balance != 10000 {
+		t.Errorf("balance after rejected mutations = %d, want 10000", balance)
+	}
+}
+
+func TestServerRequiresInterceptor(t *testing.T) {
+	_, ln := serve(t, false)
+	rpc := economypb.NewEconomyClient(dial(t, ln, proxyKey))
+	_, err := rpc.Deposit(context.Background(), &economypb.ChangeBalanceRequest{Uuid: uuid.NewString(), Amount: 1})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Deposit without interceptor: got %v, want %s", err, codes.Unauthenticated)
+	}
+}
@@ -0,0 +1,217 @@
+// Package grpcapi serves the economy over gRPC and provides a client implementing
+// the same interface, so servers and proxies in other processes share one economy.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/service"
+	"github.com/skuralll/dfeconomy/grpcapi/economypb"
+)
+
+// IdempotencyKeyMetadata is the request metadata carrying the idempotency key of a
+// mutation, see economy.WithIdempotencyKey.
+const IdempotencyKeyMetadata = "idempotency-key"
+
+// Service is the part of the economy service available over gRPC. Both
+// *service.EconomyService and *Client implement it.
+type Service interface {
+	economy.Economy
+	// Currencies returns the registered currencies, the first one is the default
+	Currencies() []economy.Currency
+	// SetBalance overwrites the balance of the player on behalf of actor. The remote
+	// economy ignores actor and records the API key of the client instead
+	SetBalance(ctx context.Context, id uuid.UUID, name, currency string, amount economy.Money, actor uuid.UUID) (economy.Transaction, error)
+	// GetTopBalances returns a page of the leaderboard
+	GetTopBalances(ctx context.Context, currency string, page, size int) ([]economy.EconomyEntry, error)
+	// GetUUIDByName returns the UUID of a player by name
+	GetUUIDByName(ctx context.Context, name string) (uuid.UUID, error)
+}
+
+// Backend is the Service served by Server. It also reports account kinds, so the
+// server can keep clients away from bank and system accounts.
+type Backend interface {
+	Service
+	// AccountKind returns whether the account is a player, bank or system account
+	AccountKind(ctx context.Context, id uuid.UUID) (economy.AccountKind, error)
+}
+
+// Server serves a Backend over gRPC. Register it with
+// economypb.RegisterEconomyServer(grpcServer, grpcapi.NewServer(svc)) on a server
+// using UnaryServerInterceptor, calls that it did not authenticate are rejected.
+type Server struct {
+	economypb.UnimplementedEconomyServer
+	svc Backend
+}
+
+// NewServer returns a gRPC server adapter for svc.
+func NewServer(svc Backend) *Server {
+	return &Server{svc: svc}
+}
+
+// requireUUID parses a UUID of a request that cannot be empty.
+func requireUUID(field, s string) (uuid.UUID, error) {
+	id, err := parseUUID(field, s)
+	if err == nil && id == uuid.Nil {
+		err = fmt.Errorf("%w: %s cannot be empty", economy.ErrValidation, field)
+	}
+	return id, err
+}
+
+// requirePlayer parses a UUID like requireUUID and rejects bank and system accounts,
+// which clients could otherwise drain without fees or rename with SetBalance. Unknown
+// accounts are left to the service.
+func (s *Server) requirePlayer(ctx context.Context, field, str string) (uuid.UUID, error) {
+	id, err := requireUUID(field, str)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	kind, err := s.svc.AccountKind(ctx, id)
+	if err != nil && !errors.Is(err, economy.ErrUnknownPlayer) {
+		return uuid.Nil, err
+	}
+	if err == nil && kind != economy.AccountPlayer {
+		return uuid.Nil, fmt.Errorf("%w: %s must be a player account", economy.ErrValidation, field)
+	}
+	return id, nil
+}
+
+func (s *Server) ListCurrencies(ctx context.Context, req *economypb.ListCurrenciesRequest) (*economypb.ListCurrenciesResponse, error) {
+	if _, err := incoming(ctx); err != nil {
+		return nil, err
+	}
+	resp := &economypb.ListCurrenciesResponse{}
+	for _, c := range s.svc.Currencies() {
+		resp.Currencies = append(resp.Currencies, toCurrencyPB(c))
+	}
+	return resp, nil
+}
+
+func (s *Server) GetBalance(ctx context.Context, req *economypb.GetBalanceRequest) (*economypb.GetBalanceResponse, error) {
+	ctx, err := incoming(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := requireUUID("uuid", req.GetUuid())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	balance, err := s.svc.GetBalance(ctx, id, req.GetCurrency())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &economypb.GetBalanceResponse{Balance: int64(balance)}, nil
+}
+
+func (s *Server) SetBalance(ctx context.Context, req *economypb.SetBalanceRequest) (*economypb.SetBalanceResponse, error) {
+	ctx, err := incoming(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.requirePlayer(ctx, "uuid", req.GetUuid())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	// The actor of the request is not trusted, the entry records the API key instead
+	entry, err := s.svc.SetBalance(ctx, id, req.GetName(), req.GetCurrency(), economy.Money(req.GetAmount()), uuid.Nil)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &economypb.SetBalanceResponse{Entry: toTransactionPB(entry)}, nil
+}
+
+func (s *Server) Withdraw(ctx context.Context, req *economypb.ChangeBalanceRequest) (*economypb.ChangeBalanceResponse, error) {
+	return s.changeBalance(ctx, req, s.svc.Withdraw)
+}
+
+func (s *Server) Deposit(ctx context.Context, req *economypb.ChangeBalanceRequest) (*economypb.ChangeBalanceResponse, error) {
+	return s.changeBalance(ctx, req, s.svc.Deposit)
+}
+
+// changeBalance serves Withdraw and Deposit with the matching method of the service.
+func (s *Server) changeBalance(ctx context.Context, req *economypb.ChangeBalanceRequest, change func(ctx context.Context, id uuid.UUID, currency string, amount economy.Money) (economy.BalanceResult, error)) (*economypb.ChangeBalanceResponse, error) {
+	ctx, err := incoming(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.requirePlayer(ctx, "uuid", req.GetUuid())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	r, err := change(ctx, id, req.GetCurrency(), economy.Money(req.GetAmount()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &economypb.ChangeBalanceResponse{
+		Currency: r.Currency,
+		Amount:   int64(r.Amount),
+		Balance:  int64(r.Balance),
+		Entry:    toTransactionPB(r.Entry),
+	}, nil
+}
+
+func (s *Server) TransferBalance(ctx context.Context, req *economypb.TransferBalanceRequest) (*economypb.TransferBalanceResponse, error) {
+	ctx, err := incoming(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from, err := s.requirePlayer(ctx, "from", req.GetFrom())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	to, err := s.requirePlayer(ctx, "to", req.GetTo())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	r, err := s.svc.TransferBalance(ctx, from, to, req.GetCurrency(), economy.Money(req.GetAmount()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &economypb.TransferBalanceResponse{
+		Currency: r.Currency,
+		Amount:   int64(r.Amount),
+		Fee:      int64(r.Fee),
+		Received: int64(r.Received),
+	}
+	for _, entry := range r.Entries {
+		resp.Entries = append(resp.Entries, toTransactionPB(entry))
+	}
+	return resp, nil
+}
+
+func (s *Server) GetTopBalances(ctx context.Context, req *economypb.GetTopBalancesRequest) (*economypb.GetTopBalancesResponse, error) {
+	ctx, err := incoming(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.svc.GetTopBalances(ctx, req.GetCurrency(), int(req.GetPage()), int(req.GetSize()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &economypb.GetTopBalancesResponse{}
+	for _, e := range list {
+		resp.Entries = append(resp.Entries, &economypb.TopEntry{Uuid: e.UUID.String(), Name: e.Name, Balance: int64(e.Balance)})
+	}
+	return resp, nil
+}
+
+func (s *Server) GetUUIDByName(ctx context.Context, req *economypb.GetUUIDByNameRequest) (*economypb.GetUUIDByNameResponse, error) {
+	ctx, err := incoming(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.svc.GetUUIDByName(ctx, req.GetName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &economypb.GetUUIDByNameResponse{Uuid: id.String()}, nil
+}
+
+// Implementation completeness checks
+var _ Backend = (*service.EconomyService)(nil)
+var _ economypb.EconomyServer = (*Server)(nil)
@@ -0,0 +1,327 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+const (
+	// defaultPageSize is the page size of the leaderboard and histories when the
+	// request has none.
+	defaultPageSize = 10
+	// MaxPageSize is the largest page size a request may ask for.
+	MaxPageSize = 100
+)
+
+// Amounts are decimal strings in units of their currency, e.g. "12.50", and formatted
+// amounts include the currency symbol or name for display.
+
+type balanceResponse struct {
+	UUID      string `json:"uuid"`
+	Player    string `json:"player,omitempty"` // Empty when looked up by UUID
+	Currency  string `json:"currency"`
+	Balance   string `json:"balance"`
+	Formatted string `json:"formatted"`
+}
+
+type setBalanceRequest struct {
+	Currency string `json:"currency"` // Empty for the default currency
+	Amount   string `json:"amount"`
+}
+
+type transferRequest struct {
+	From     string `json:"from"` // Player name or UUID
+	To       string `json:"to"`   // Player name or UUID
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+}
+
+type transferResponse struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`   // Debited from the sender
+	Fee      string `json:"fee"`      // Part of the amount kept as fee
+	Received string `json:"received"` // Credited to the receiver
+}
+
+type topEntry struct {
+	Rank    int    `json:"rank"`
+	Player  string `json:"player"`
+	UUID    string `json:"uuid"`
+	Balance string `json:"balance"`
+}
+
+type historyEntry struct {
+	ID          uint      `json:"id"`
+	Type        string    `json:"type"`
+	Currency    string    `json:"currency"`
+	From        string    `json:"from,omitempty"` // Empty when money entered the economy
+	FromName    string    `json:"from_name,omitempty"`
+	To          string    `json:"to"`
+	ToName      string    `json:"to_name,omitempty"`
+	Amount      string    `json:"amount"`
+	FromBalance string    `json:"from_balance"`
+	ToBalance   string    `json:"to_balance"`
+	Actor       string    `json:"actor,omitempty"`      // Empty for the system
+	ActorName   string    `json:"actor_name,omitempty"` // API key or other client that made the change
+	Reason      string    `json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// GET /v1/players/{player}/balance?currency=
+
+func (s *Server) getBalance(ctx context.Context, r *http.Request) (any, error) {
+	ref := r.PathValue("player")
+	id, err := s.resolvePlayer(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.svc.Currency(r.URL.Query().Get("currency"))
+	if err != nil {
+		return nil, err
+	}
+	balance, err := s.svc.GetBalance(ctx, id, c.Name)
+	if err != nil {
+		return nil, err
+	}
+	return newBalanceResponse(id, playerName(ref), c, balance), nil
+}
+
+// PUT /v1/players/{player}/balance {"currency": "", "amount": "100.00"}
+
+func (s *Server) setBalance(ctx context.Context, r *http.Request) (any, error) {
+	name := r.PathValue("player")
+	if playerName(name) == "" {
+		// The name is stored with the account, so the player cannot be given by UUID
+		return nil, service.NewValidationError("player", "must be a name to set the balance")
+	}
+	var req setBalanceRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	c, err := s.svc.Currency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := s.svc.ParseAmount(c.Name, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.svc.GetUUIDByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.svc.SetBalance(ctx, id, name, c.Name, amount, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	return newBalanceResponse(id, name, c, entry.ToBalance), nil
+}
+
+// POST /v1/transfers {"from": "Steve", "to": "Alex", "currency": "", "amount": "10"}
+
+func (s *Server) transfer(ctx context.Context, r *http.Request) (any, error) {
+	var req transferRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	c, err := s.svc.Currency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := s.svc.ParseAmount(c.Name, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	from, err := s.resolvePlayer(ctx, req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.resolvePlayer(ctx, req.To)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.svc.TransferBalance(ctx, from, to, c.Name, amount)
+	if err != nil {
+		return nil, err
+	}
+	return transferResponse{
+		From:     from.String(),
+		To:       to.String(),
+		Currency: c.Name,
+		Amount:   result.Amount.Format(c.Decimals),
+		Fee:      result.Fee.Format(c.Decimals),
+		Received: result.Received.Format(c.Decimals),
+	}, nil
+}
+
+// GET /v1/top?currency=&page=&size=
+
+func (s *Server) getTop(ctx context.Context, r *http.Request) (any, error) {
+	page, size, err := pagination(r)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.svc.Currency(r.URL.Query().Get("currency"))
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.svc.GetTopBalances(ctx, c.Name, page, size)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]topEntry, 0, len(list))
+	for i, e := range list {
+		entries = append(entries, topEntry{
+			Rank:    (page-1)*size + i + 1,
+			Player:  e.Name,
+			UUID:    e.UUID.String(),
+			Balance: e.Balance.Format(c.Decimals),
+		})
+	}
+	return entries, nil
+}
+
+// GET /v1/players/{player}/history?page=&size=&counterparty=
+
+func (s *Server) getHistory(ctx context.Context, r *http.Request) (any, error) {
+	page, size, err := pagination(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.resolvePlayer(ctx, r.PathValue("player"))
+	if err != nil {
+		return nil, err
+	}
+	var counterparty uuid.UUID
+	if ref := r.URL.Query().Get("counterparty"); ref != "" {
+		if counterparty, err = s.resolvePlayer(ctx, ref); err != nil {
+			return nil, err
+		}
+	}
+	list, err := s.svc.GetHistory(ctx, id, counterparty, page, size)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]historyEntry, 0, len(list))
+	for _, e := range list {
+		entries = append(entries, s.newHistoryEntry(e))
+	}
+	return entries, nil
+}
+
+// resolvePlayer returns the UUID of a player given by UUID or name.
+func (s *Server) resolvePlayer(ctx context.Context, ref string) (uuid.UUID, error) {
+	if ref == "" {
+		return uuid.Nil, service.NewValidationError("player", "cannot be empty")
+	}
+	id, err := uuid.Parse(ref)
+	if err != nil {
+		// Names only resolve to player accounts
+		return s.svc.GetUUIDByName(ctx, ref)
+	}
+	// Bank and system accounts are not reachable through the API, a transfer key could
+	// otherwise drain them without fees
+	kind, err := s.svc.AccountKind(ctx, id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if kind != economy.AccountPlayer {
+		return uuid.Nil, service.NewValidationError("player", "must be a player account")
+	}
+	return id, nil
+}
+
+// playerName returns ref if it is a player name rather than a UUID.
+func playerName(ref string) string {
+	if _, err := uuid.Parse(ref); err == nil {
+		return ""
+	}
+	return ref
+}
+
+func newBalanceResponse(id uuid.UUID, name string, c economy.Currency, balance economy.Money) balanceResponse {
+	return balanceResponse{
+		UUID:      id.String(),
+		Player:    name,
+		Currency:  c.Name,
+		Balance:   balance.Format(c.Decimals),
+		Formatted: c.Format(balance),
+	}
+}
+
+func (s *Server) newHistoryEntry(e economy.Transaction) historyEntry {
+	// Currencies removed from the configuration use the default scale
+	decimals := economy.DefaultScale
+	if c, err := s.svc.Currency(e.Currency); err == nil {
+		decimals = c.Decimals
+	}
+	return historyEntry{
+		ID:          e.ID,
+		Type:        string(e.Type),
+		Currency:    e.Currency,
+		From:        uuidString(e.From),
+		FromName:    e.FromName,
+		To:          e.To.String(),
+		ToName:      e.ToName,
+		Amount:      e.Amount.Format(decimals),
+		FromBalance: e.FromBalance.Format(decimals),
+		ToBalance:   e.ToBalance.Format(decimals),
+		Actor:       uuidString(e.Actor),
+		ActorName:   e.ActorName,
+		Reason:      e.Reason,
+		CreatedAt:   e.CreatedAt,
+	}
+}
+
+// uuidString returns the UUID as string, empty for uuid.Nil.
+func uuidString(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+	return id.String()
+}
+
+// decodeBody decodes the JSON request body into v, rejecting unknown fields.
+func decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return service.NewValidationError("body", "must be a valid JSON object: "+err.Error())
+	}
+	return nil
+}
+
+// pagination reads the page and size query parameters.
+func pagination(r *http.Request) (page, size int, err error) {
+	if page, err = queryInt(r, "page", 1); err != nil {
+		return 0, 0, err
+	}
+	if size, err = queryInt(r, "size", defaultPageSize); err != nil {
+		return 0, 0, err
+	}
+	if size > MaxPageSize {
+		return 0, 0, service.NewValidationError("size", "must be at most "+strconv.Itoa(MaxPageSize))
+	}
+	return page, size, nil
+}
+
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, service.NewValidationError(name, "must be an integer")
+	}
+	return n, nil
+}
@@ -0,0 +1,175 @@
+// Package httpapi serves the economy service as a JSON API for websites and bots,
+// authenticated with the API keys of config.HTTPConfig.
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/config"
+	"github.com/skuralll/dfeconomy/economy/service"
+)
+
+// Scopes granted to API keys in config.APIKey.Scopes
+const (
+	ScopeBalanceRead  = config.ScopeBalanceRead  // Read balances
+	ScopeBalanceWrite = config.ScopeBalanceWrite // Set balances
+	ScopeTransfer     = config.ScopeTransfer     // Transfer money between players
+	ScopeTopRead      = config.ScopeTopRead      // Read the leaderboard
+	ScopeHistoryRead  = config.ScopeHistoryRead  // Read transaction histories
+)
+
+const (
+	// RequestTimeout limits the time a request may spend in the economy service.
+	RequestTimeout = 5 * time.Second
+	// ShutdownTimeout limits the time requests in progress get to finish on shutdown.
+	ShutdownTimeout = 10 * time.Second
+	// maxBodySize limits the size of request bodies in bytes.
+	maxBodySize = 64 << 10
+)
+
+// Server exposes the economy service as a JSON API authenticated with the API keys
+// of the service configuration.
+type Server struct {
+	svc *service.EconomyService
+	mux *http.ServeMux
+}
+
+// handlerFunc handles an authenticated request and returns the response body.
+type handlerFunc func(ctx context.Context, r *http.Request) (any, error)
+
+// New returns an API server for svc.
+func New(svc *service.EconomyService) *Server {
+	s := &Server{svc: svc, mux: http.NewServeMux()}
+	s.handle("GET /v1/players/{player}/balance", ScopeBalanceRead, s.getBalance)
+	s.handle("PUT /v1/players/{player}/balance", ScopeBalanceWrite, s.setBalance)
+	s.handle("GET /v1/players/{player}/history", ScopeHistoryRead, s.getHistory)
+	s.handle("GET /v1/top", ScopeTopRead, s.getTop)
+	s.handle("POST /v1/transfers", ScopeTransfer, s.transfer)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the API on addr until ctx is done, then shuts down gracefully
+// and waits up to ShutdownTimeout for requests in progress.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves the API on ln until ctx is done like ListenAndServe.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	errs := make(chan error, 1)
+	go func() { errs <- srv.Serve(ln) }()
+	slog.Info("REST API listening", "addr", ln.Addr().String())
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("REST API stopped")
+	return nil
+}
+
+// handle registers a handler that requires an API key granting scope.
+func (s *Server) handle(pattern, scope string, h handlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		key, ok := s.authenticate(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing or invalid API key", Code: "unauthorized"})
+			return
+		}
+		if !key.Allows(scope) {
+			writeJSON(w, http.StatusForbidden, errorResponse{Error: "API key lacks scope " + scope, Code: "forbidden"})
+			return
+		}
+
+		// Mutations are recorded in the ledger with the key name as actor
+		ctx, cancel := context.WithTimeout(economy.WithActorName(r.Context(), key.Name), RequestTimeout)
+		defer cancel()
+		if idempotencyKey := r.Header.Get("Idempotency-Key"); idempotencyKey != "" {
+			ctx = economy.WithIdempotencyKey(ctx, idempotencyKey)
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+		body, err := h(ctx, r)
+		if err != nil {
+			status, resp := errorStatus(err)
+			if status == http.StatusInternalServerError {
+				slog.Error("REST API request failed", "key", key.Name, "method", r.Method, "path", r.URL.Path, "error", err)
+			}
+			writeJSON(w, status, resp)
+			return
+		}
+		writeJSON(w, http.StatusOK, body)
+	})
+}
+
+// authenticate returns the configured API key sent as bearer token. Keys are read
+// from the current configuration, so reloads rotate them.
+func (s *Server) authenticate(r *http.Request) (config.APIKey, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return config.APIKey{}, false
+	}
+	return config.FindAPIKey(s.svc.Config().HTTP.APIKeys, token)
+}
+
+// errorResponse is the body of failed requests.
+type errorResponse struct {
+	Error string `json:"error"` // Human readable message
+	Code  string `json:"code"`  // Machine readable error kind
+}
+
+// errorStatus maps a service error to an HTTP status and response body. Internal
+// errors are not described to the client.
+func errorStatus(err error) (int, errorResponse) {
+	var insufficient *economy.InsufficientFundsError
+	switch {
+	case errors.As(err, &insufficient):
+		return http.StatusConflict, errorResponse{err.Error(), "insufficient_funds"}
+	case errors.Is(err, service.ErrValidation):
+		return http.StatusBadRequest, errorResponse{err.Error(), "validation"}
+	case errors.Is(err, service.ErrUnknownPlayer):
+		return http.StatusNotFound, errorResponse{err.Error(), "unknown_player"}
+	case errors.Is(err, service.ErrForbidden):
+		return http.StatusForbidden, errorResponse{err.Error(), "forbidden"}
+	case errors.Is(err, service.ErrCancelled):
+		return http.StatusConflict, errorResponse{err.Error(), "cancelled"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, errorResponse{"request timeout", "timeout"}
+	default:
+		return http.StatusInternalServerError, errorResponse{"internal error", "internal"}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Debug("Failed to write REST API response", "error", err)
+	}
+}
@@ -0,0 +1,293 @@
+package httpapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/economy/config"
+	"github.com/skuralll/dfeconomy/economy/service"
+	"github.com/skuralll/dfeconomy/httpapi"
+	"github.com/skuralll/dfeconomy/internal/db"
+)
+
+const (
+	adminKey  = "admin-key-0123456789"
+	readerKey = "reader-key-0123456789"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *service.EconomyService) {
+	t.Helper()
+	cfg := config.Config{DefaultBalance: 100}
+	cfg.HTTP.APIKeys = []config.APIKey{
+		{Name: "admin", Key: adminKey, Scopes: []string{"*"}},
+		{Name: "reader", Key: readerKey, Scopes: []string{"balance:read", "top:read"}},
+	}
+	svc, err := service.NewEconomyServiceWithDB(cfg, nil, service.NewMemoryDB())
+	if err != nil {
+		t.Fatalf("NewEconomyServiceWithDB: %v", err)
+	}
+	for _, name := range []string{"steve", "alex"} {
+		if _, err := svc.RegisterUser(context.Background(), uuid.New(), name); err != nil {
+			t.Fatalf("RegisterUser: %v", err)
+		}
+	}
+	srv := httptest.NewServer(httpapi.New(svc))
+	t.Cleanup(srv.Close)
+	return srv, svc
+}
+
+// call sends a request and decodes the JSON response into out, returning the status.
+func call(t *testing.T, srv *httptest.Server, key, method, path, body string, out any, header ...string) int {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+type errorBody struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+func TestAuthentication(t *testing.T) {
+	srv, svc := newTestServer(t)
+	var e errorBody
+	if status := call(t, srv, "", "GET", "/v1/top", "", &e); status != http.StatusUnauthorized || e.Code != "unauthorized" {
+		t.Errorf("without key: got %d %+v, want 401", status, e)
+	}
+	if status := call(t, srv, "wrong-key-0123456789", "GET", "/v1/top", "", &e); status != http.StatusUnauthorized {
+		t.Errorf("with unknown key: got %d, want 401", status)
+	}
+	if status := call(t, srv, readerKey, "POST", "/v1/transfers", `{"from":"steve","to":"alex","amount":"1"}`, &e); status != http.StatusForbidden || e.Code != "forbidden" {
+		t.Errorf("without scope: got %d %+v, want 403", status, e)
+	}
+	if status := call(t, srv, readerKey, "GET", "/v1/top", "", nil); status != http.StatusOK {
+		t.Errorf("with scope: got %d, want 200", status)
+	}
+
+	// Reloads rotate the keys
+	cfg := svc.Config()
+	cfg.HTTP.APIKeys = cfg.HTTP.APIKeys[:1]
+	if err := svc.Reload(context.Background(), cfg); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if status := call(t, srv, readerKey, "GET", "/v1/top", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("with removed key: got %d, want 401", status)
+	}
+}
+
+func TestBalanceAndTransfer(t *testing.T) {
+	srv, svc := newTestServer(t)
+	steve, err := svc.GetUUIDByName(context.Background(), "steve")
+	if err != nil {
+		t.Fatalf("GetUUIDByName: %v", err)
+	}
+
+	var balance struct {
+		UUID, Player, Currency, Balance, Formatted string
+	}
+	if status := call(t, srv, readerKey, "GET", "/v1/players/steve/balance", "", &balance); status != http.StatusOK {
+		t.Fatalf("balance by name: got %d", status)
+	}
+	if balance.UUID != steve.String() || balance.Player != "steve" || balance.Currency != "money" || balance.Balance != "100.00" {
+		t.Errorf("balance by name = %+v", balance)
+	}
+	if status := call(t, srv, readerKey, "GET", "/v1/players/"+steve.String()+"/balance", "", &balance); status != http.StatusOK || balance.Balance != "100.00" {
+		t.Errorf("balance by UUID: got %d %+v", status, balance)
+	}
+
+	var transfer struct {
+		Amount, Fee, Received string
+	}
+	body := `{"from":"steve","to":"alex","amount":"25.50"}`
+	for range 2 {
+		status := call(t, srv, adminKey, "POST", "/v1/transfers", body, &transfer, "Idempotency-Key", "order-1")
+		if status != http.StatusOK || transfer.Amount != "25.50" || transfer.Received != "25.50" {
+			t.Fatalf("transfer: got %d %+v", status, transfer)
+		}
+	}
+	call(t, srv, readerKey, "GET", "/v1/players/alex/balance", "", &balance)
+	if balance.Balance != "125.50" {
+		t.Errorf("receiver balance after a retried transfer = %s, want 125.50", balance.Balance)
+	}
+
+	if status := call(t, srv, adminKey, "PUT", "/v1/players/steve/balance", `{"amount":"10"}`, &balance); status != http.StatusOK || balance.Balance != "10.00" {
+		t.Errorf("set: got %d %+v", status, balance)
+	}
+	var top []struct {
+		Rank    int
+		Player  string
+		Balance string
+	}
+	if status := call(t, srv, readerKey, "GET", "/v1/top?size=1", "", &top); status != http.StatusOK || len(top) != 1 || top[0].Player != "alex" || top[0].Rank != 1 {
+		t.Errorf("top: got %d %+v", status, top)
+	}
+	var history []struct {
+		Type, From, To, Amount string
+		ActorName              string `json:"actor_name"`
+	}
+	if status := call(t, srv, adminKey, "GET", "/v1/players/steve/history?counterparty=alex", "", &history); status != http.StatusOK || len(history) != 1 || history[0].Type != "transfer" || history[0].Amount != "25.50" {
+		t.Errorf("history: got %d %+v", status, history)
+	}
+	// Mutations are attributed to the API key
+	if status := call(t, srv, adminKey, "GET", "/v1/players/steve/history", "", &history); status != http.StatusOK || len(history) != 3 {
+		t.Fatalf("history: got %d %+v", status, history)
+	}
+	for _, e := range history {
+		if want := map[string]string{"register": "", "transfer": "admin", "set": "admin"}[e.Type]; e.ActorName != want {
+			t.Errorf("%s entry has actor name %q, want %q", e.Type, e.ActorName, want)
+		}
+	}
+}
+
+// TestNonPlayerAccounts checks that bank and system accounts given by UUID cannot be
+// read or moved through the API.
+func TestNonPlayerAccounts(t *testing.T) {
+	srv, svc := newTestServer(t)
+	ctx := context.Background()
+	steve, err := svc.GetUUIDByName(ctx, "steve")
+	if err != nil {
+		t.Fatalf("GetUUIDByName: %v", err)
+	}
+	bank, err := svc.CreateBank(ctx, steve, "guild")
+	if err != nil {
+		t.Fatalf("CreateBank: %v", err)
+	}
+	if _, err := svc.DepositBank(ctx, steve, "guild", "", 5000); err != nil {
+		t.Fatalf("DepositBank: %v", err)
+	}
+	treasury, err := svc.SystemAccount(economy.TreasuryAccountName)
+	if err != nil {
+		t.Fatalf("SystemAccount: %v", err)
+	}
+
+	for _, id := range []uuid.UUID{bank.UUID, treasury} {
+		tests := []struct {
+			method, path, body string
+		}{
+			{"GET", "/v1/players/" + id.String() + "/balance", ""},
+			{"GET", "/v1/players/" + id.String() + "/history", ""},
+			{"POST", "/v1/transfers", `{"from":"` + id.String() + `","to":"alex","amount":"1"}`},
+			{"POST", "/v1/transfers", `{"from":"steve","to":"` + id.String() + `","amount":"1"}`},
+		}
+		for _, tt := range tests {
+			var e errorBody
+			if status := call(t, srv, adminKey, tt.method, tt.path, tt.body, &e); status != http.StatusBadRequest || e.Code != "validation" {
+				t.Errorf("%s %s %s: got %d %+v, want 400 validation", tt.method, tt.path, tt.body, status, e)
+			}
+		}
+	}
+	if balance, err := svc.GetBalance(ctx, bank.UUID, ""); err != nil || balance != 5000 {
+		t.Errorf("bank balance = %v, %v, want 50.00", balance, err)
+	}
+}
+
+// timeoutDB fails leaderboard queries like a database that did not answer in time.
+type timeoutDB struct {
+	db.DB
+}
+
+func (timeoutDB) Top(ctx context.Context, currency string, page, size int) ([]economy.EconomyEntry, error) {
+	return nil, db.WrapDatabaseError("top query", context.DeadlineExceeded)
+}
+
+func TestTimeout(t *testing.T) {
+	cfg := config.Config{DefaultBalance: 100}
+	cfg.HTTP.APIKeys = []config.APIKey{{Name: "reader", Key: readerKey, Scopes: []string{"top:read"}}}
+	svc, err := service.NewEconomyServiceWithDB(cfg, nil, timeoutDB{service.NewMemoryDB()})
+	if err != nil {
+		t.Fatalf("NewEconomyServiceWithDB: %v", err)
+	}
+	srv := httptest.NewServer(httpapi.New(svc))
+	t.Cleanup(srv.Close)
+
+	var e errorBody
+	if status := call(t, srv, readerKey, "GET", "/v1/top", "", &e); status != http.StatusGatewayTimeout || e.Code != "timeout" {
+		t.Errorf("top with expired deadline: got %d %+v, want 504 timeout", status, e)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	srv, _ := newTestServer(t)
+	tests := []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{"GET", "/v1/players/nobody/balance", "", http.StatusNotFound, "unknown_player"},
+		{"GET", "/v1/players/steve/balance?currency=gems", "", http.StatusBadRequest, "validation"},
+		{"GET", "/v1/top?size=1000", "", http.StatusBadRequest, "validation"},
+		{"GET", "/v1/top?page=x", "", http.StatusBadRequest, "validation"},
+		{"POST", "/v1/transfers", `{"from":"steve","to":"alex","amount":"1000"}`, http.StatusConflict, "insufficient_funds"},
+		{"POST", "/v1/transfers", `{"from":"steve","to":"alex","amount":"-1"}`, http.StatusBadRequest, "validation"},
+		{"POST", "/v1/transfers", `{"from":"steve","to":"nobody","amount":"1"}`, http.StatusNotFound, "unknown_player"},
+		{"POST", "/v1/transfers", `{"from":"steve","to":"alex","amount":"1","note":"x"}`, http.StatusBadRequest, "validation"},
+		{"POST", "/v1/transfers", `not json`, http.StatusBadRequest, "validation"},
+		{"PUT", "/v1/players/" + uuid.NewString() + "/balance", `{"amount":"1"}`, http.StatusBadRequest, "validation"},
+	}
+	for _, tt := range tests {
+		var e errorBody
+		if status := call(t, srv, adminKey, tt.method, tt.path, tt.body, &e); status != tt.status || e.Code != tt.code {
+			t.Errorf("%s %s %s: got %d %+v, want %d %s", tt.method, tt.path, tt.body, status, e, tt.status, tt.code)
+		}
+	}
+}
+
+func TestServeShutdown(t *testing.T) {
+	_, svc := newTestServer(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- httpapi.New(svc).Serve(ctx, ln) }()
+
+	req, _ := http.NewRequest("GET", "http://"+ln.Addr().String()+"/v1/top", nil)
+	req.Header.Set("Authorization", "Bearer "+readerKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /v1/top: %v", err)
+	}
+	resp.Body.Close()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve returned %v after shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after the context was cancelled")
+	}
+	if _, err := http.DefaultClient.Do(req); err == nil || errors.Is(err, context.Canceled) {
+		t.Errorf("request after shutdown: got %v, want a connection error", err)
+	}
+}
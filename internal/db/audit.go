@@ -0,0 +1,133 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"gorm.io/gorm"
+)
+
+func (d *DBGorm) Audit(ctx context.Context) ([]economy.BalanceAudit, error) {
+	// Sum the ledger per account and currency, the sender is debited and the receiver credited
+	type sum struct {
+		UUID     string
+		Currency string
+		Amount   economy.Money
+	}
+	var accounts []Account
+	var balances []Balance
+	var credits, debits []sum
+	// Read everything from one snapshot, so transfers committing meanwhile cannot
+	// show up in the balances but not in the ledger
+	err := d.transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Select("uuid", "name", "kind").Find(&accounts).Error; err != nil {
+			return WrapDatabaseError("account query", err)
+		}
+		if err := tx.Select("account_uuid", "currency", "amount").Find(&balances).Error; err != nil {
+			return WrapDatabaseError("balance query", err)
+		}
+		err := tx.Model(&Transaction{}).Select("to_uuid AS uuid, currency, SUM(amount) AS amount").
+			Where("to_uuid <> ''").Group("to_uuid, currency").Scan(&credits).Error
+		if err != nil {
+			return WrapDatabaseError("ledger credit query", err)
+		}
+		err = tx.Model(&Transaction{}).Select("from_uuid AS uuid, currency, SUM(amount) AS amount").
+			Where("from_uuid <> ''").Group("from_uuid, currency").Scan(&debits).Error
+		if err != nil {
+			return WrapDatabaseError("ledger debit query", err)
+		}
+		return nil
+	}, snapshotTx)
+	if err != nil {
+		return nil, err
+	}
+
+	a := newAuditor()
+	for _, account := range accounts {
+		a.accounts[parseUUID(account.UUID)] = account
+	}
+	for _, b := range balances {
+		a.entry(parseUUID(b.AccountUUID), b.Currency).Balance = b.Amount
+	}
+	for _, c := range credits {
+		a.entry(parseUUID(c.UUID), c.Currency).Ledger += c.Amount
+	}
+	for _, c := range debits {
+		a.entry(parseUUID(c.UUID), c.Currency).Ledger -= c.Amount
+	}
+	return a.result(), nil
+}
+
+func (d *DBMemory) Audit(ctx context.Context) ([]economy.BalanceAudit, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "audit"); err != nil {
+		return nil, err
+	}
+
+	a := newAuditor()
+	for id, account := range d.accounts {
+		a.accounts[id] = Account{Name: account.name, Kind: string(account.kind)}
+	}
+	for key, amount := range d.balances {
+		a.entry(key.id, key.currency).Balance = amount
+	}
+	for _, t := range d.ledger {
+		if t.To != uuid.Nil {
+			a.entry(t.To, t.Currency).Ledger += t.Amount
+		}
+		if t.From != uuid.Nil {
+			a.entry(t.From, t.Currency).Ledger -= t.Amount
+		}
+	}
+	return a.result(), nil
+}
+
+// auditor merges balances and ledger sums into audit entries.
+type auditor struct {
+	accounts map[uuid.UUID]Account
+	entries  map[balanceKey]*economy.BalanceAudit
+}
+
+func newAuditor() *auditor {
+	return &auditor{
+		accounts: make(map[uuid.UUID]Account),
+		entries:  make(map[balanceKey]*economy.BalanceAudit),
+	}
+}
+
+// entry returns the audit entry of the balance, creating it if missing.
+func (a *auditor) entry(id uuid.UUID, currency string) *economy.BalanceAudit {
+	key := balanceKey{id, currency}
+	if e, ok := a.entries[key]; ok {
+		return e
+	}
+	account := a.accounts[id]
+	e := &economy.BalanceAudit{
+		Account:  id,
+		Name:     account.Name,
+		Kind:     economy.AccountKind(account.Kind),
+		Currency: currency,
+	}
+	a.entries[key] = e
+	return e
+}
+
+// result returns the entries ordered by currency, name and UUID.
+func (a *auditor) result() []economy.BalanceAudit {
+	result := make([]economy.BalanceAudit, 0, len(a.entries))
+	for _, e := range a.entries {
+		result = append(result, *e)
+	}
+	slices.SortFunc(result, func(x, y economy.BalanceAudit) int {
+		return cmp.Or(
+			cmp.Compare(x.Currency, y.Currency),
+			cmp.Compare(x.Name, y.Name),
+			cmp.Compare(x.Account.String(), y.Account.String()),
+		)
+	})
+	return result
+}
@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (d *DBGorm) CreateBank(ctx context.Context, id uuid.UUID, name string, owner uuid.UUID, balances map[string]economy.Money) ([]economy.Transaction, error) {
+	// Basic data integrity checks
+	if id == uuid.Nil || owner == uuid.Nil {
+		return nil, NewValidationError("uuid", "cannot be nil")
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, NewValidationError("name", "cannot be empty")
+	}
+
+	var entries []Transaction
+	err := d.transaction(ctx, func(tx *gorm.DB) error {
+		// Check owner is a player
+		if err := findAccount(tx, owner, economy.AccountPlayer, "owner"); err != nil {
+			return err
+		}
+		// The unique bank name rejects names taken by another bank, even concurrently
+		var err error
+		entries, err = createAccount(tx, id, name, economy.AccountBank, balances, owner)
+		if isDuplicate(err) {
+			return NewValidationError("name", "is already taken")
+		}
+		if err != nil {
+			return err
+		}
+		err = tx.Create(&BankMember{
+			BankUUID:   id.String(),
+			MemberUUID: owner.String(),
+			Role:       string(economy.BankRoleOwner),
+		}).Error
+		if err != nil {
+			return WrapDatabaseError("bank member creation", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toEntries(entries), nil
+}
+
+func (d *DBGorm) Bank(ctx context.Context, name string) (economy.Bank, error) {
+	// Basic data integrity checks
+	if strings.TrimSpace(name) == "" {
+		return economy.Bank{}, NewValidationError("name", "cannot be empty")
+	}
+
+	var account Account
+	err := d.db.WithContext(ctx).Where("name = ? AND kind = ?", name, economy.AccountBank).First(&account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return economy.Bank{}, NewNotFoundError("bank")
+		}
+		return economy.Bank{}, WrapDatabaseError("bank query", err)
+	}
+	return bankMembers(d.db.WithContext(ctx), account)
+}
+
+// lockBank locks the bank account until the transaction ends and returns it with its
+// members, so concurrent membership changes see each other.
+func lockBank(tx *gorm.DB, id uuid.UUID) (economy.Bank, error) {
+	var account Account
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("uuid = ? AND kind = ?", id.String(), economy.AccountBank).First(&account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return economy.Bank{}, NewNotFoundError("bank")
+		}
+		return economy.Bank{}, WrapDatabaseError("bank lock", err)
+	}
+	return bankMembers(tx, account)
+}
+
+// bankMembers returns the bank account with its members.
+func bankMembers(tx *gorm.DB, account Account) (economy.Bank, error) {
+	// Fetch members with their display names in the order they joined
+	var rows []struct {
+		MemberUUID string
+		Name       string
+		Role       string
+	}
+	err := tx.Model(&BankMember{}).
+		Select("bank_members.member_uuid, accounts.name, bank_members.role").
+		Joins("JOIN accounts ON accounts.uuid = bank_members.member_uuid AND accounts.deleted_at IS NULL").
+		Where("bank_members.bank_uuid = ?", account.UUID).
+		Order("bank_members.id").Scan(&rows).Error
+	if err != nil {
+		return economy.Bank{}, WrapDatabaseError("bank member query", err)
+	}
+
+	bank := economy.Bank{UUID: parseUUID(account.UUID), Name: account.Name}
+	for _, row := range rows {
+		bank.Members = append(bank.Members, economy.BankMember{
+			UUID: parseUUID(row.MemberUUID),
+			Name: row.Name,
+			Role: economy.BankRole(row.Role),
+		})
+	}
+	return bank, nil
+}
+
+func (d *DBGorm) BankTransfer(ctx context.Context, bankID, fromID, toID uuid.UUID, currency string, amount economy.Money, check func(economy.Bank) error) ([]economy.Transaction, error) {
+	actor, err := bankTransferActor(bankID, fromID, toID)
+	if err != nil {
+		return nil, err
+	}
+	// The check runs with the bank locked, so a concurrent demotion takes effect first
+	return d.transfer(ctx, fromID, toID, currency, amount, 0, uuid.Nil, actor, func(tx *gorm.DB) error {
+		bank, err := lockBank(tx, bankID)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			return check(bank)
+		}
+		return nil
+	})
+}
+
+// bankTransferActor returns the member moving money to or from the bank.
+func bankTransferActor(bankID, fromID, toID uuid.UUID) (uuid.UUID, error) {
+	switch bankID {
+	case fromID:
+		return toID, nil
+	case toID:
+		return fromID, nil
+	}
+	return uuid.Nil, NewValidationError("bank", "must be the sender or the receiver")
+}
+
+func (d *DBGorm) SetBankMember(ctx context.Context, bankID, memberID uuid.UUID, role economy.BankRole, check func(economy.Bank) error) error {
+	// Basic data integrity checks
+	if bankID == uuid.Nil || memberID == uuid.Nil {
+		return NewValidationError("uuid", "cannot be nil")
+	}
+	if !role.Valid() {
+		return NewValidationError("role", "is unknown")
+	}
+
+	return d.transaction(ctx, func(tx *gorm.DB) error {
+		bank, err := lockBank(tx, bankID)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(bank); err != nil {
+				return err
+			}
+		}
+		if err := findAccount(tx, memberID, economy.AccountPlayer, "member"); err != nil {
+			return err
+		}
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "bank_uuid"}, {Name: "member_uuid"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+		}).Create(&BankMember{
+			BankUUID:   bankID.String(),
+			MemberUUID: memberID.String(),
+			Role:       string(role),
+		}).Error
+		if err != nil {
+			return WrapDatabaseError("bank member update", err)
+		}
+		return nil
+	})
+}
+
+func (d *DBGorm) RemoveBankMember(ctx context.Context, bankID, memberID uuid.UUID, check func(economy.Bank) error) error {
+	// Basic data integrity checks
+	if bankID == uuid.Nil || memberID == uuid.Nil {
+		return NewValidationError("uuid", "cannot be nil")
+	}
+
+	return d.transaction(ctx, func(tx *gorm.DB) error {
+		bank, err := lockBank(tx, bankID)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(bank); err != nil {
+				return err
+			}
+		}
+		// Hard delete so the member can be added again despite the unique index
+		result := tx.Unscoped().
+			Where("bank_uuid = ? AND member_uuid = ?", bankID.String(), memberID.String()).Delete(&BankMember{})
+		if result.Error != nil {
+			return WrapDatabaseError("bank member removal", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return NewNotFoundError("member")
+		}
+		return nil
+	})
+}
+
+// findAccount checks that the account exists and is of the given kind.
+func findAccount(tx *gorm.DB, id uuid.UUID, kind economy.AccountKind, resource string) error {
+	err := tx.Where("uuid = ? AND kind = ?", id.String(), kind).First(&Account{}).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return NewNotFoundError(resource)
+		}
+		return WrapDatabaseError(resource+" query", err)
+	}
+	return nil
+}
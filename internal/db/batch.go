@@ -0,0 +1,285 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"gorm.io/gorm"
+)
+
+// OperationBatch is recorded with idempotency keys of batches.
+const OperationBatch = "batch"
+
+// batchPlan is a validated batch: the net change of every balance and the transfers
+// between debited and credited accounts that realize them.
+type batchPlan struct {
+	changes   []batchChange   // Sorted by currency and account
+	transfers []batchTransfer // Recorded as ledger entries in this order
+}
+
+// batchChange is the net change of one balance.
+type batchChange struct {
+	key   balanceKey
+	delta economy.Money
+}
+
+// batchTransfer moves amount from a debited to a credited account.
+type batchTransfer struct {
+	currency string
+	from, to uuid.UUID
+	amount   economy.Money
+}
+
+// planBatch nets the legs per balance and checks that every currency balances.
+// Debits are matched with credits in account order, so the same legs always
+// produce the same ledger entries.
+func planBatch(legs []economy.Leg) (batchPlan, error) {
+	if len(legs) == 0 {
+		return batchPlan{}, NewValidationError("legs", "cannot be empty")
+	}
+	net := make(map[balanceKey]economy.Money)
+	for _, leg := range legs {
+		if leg.Account == uuid.Nil {
+			return batchPlan{}, NewValidationError("account", "cannot be nil")
+		}
+		if strings.TrimSpace(leg.Currency) == "" {
+			return batchPlan{}, NewValidationError("currency", "cannot be empty")
+		}
+		if leg.Amount == 0 || leg.Amount == math.MinInt64 {
+			return batchPlan{}, NewValidationError("amount", "must be a non-zero amount")
+		}
+		key := balanceKey{leg.Account, leg.Currency}
+		sum, err := net[key].Add(leg.Amount)
+		if err != nil {
+			return batchPlan{}, NewValidationError("amount", "of account "+leg.Account.String()+" overflows")
+		}
+		net[key] = sum
+	}
+
+	var plan batchPlan
+	for key, delta := range net {
+		if delta != 0 {
+			plan.changes = append(plan.changes, batchChange{key, delta})
+		}
+	}
+	if len(plan.changes) == 0 {
+		return batchPlan{}, NewValidationError("legs", "cancel each other out")
+	}
+	slices.SortFunc(plan.changes, func(a, b batchChange) int {
+		return cmp.Or(cmp.Compare(a.key.currency, b.key.currency), cmp.Compare(a.key.id.String(), b.key.id.String()))
+	})
+
+	// Match the debits and credits of every currency
+	for start := 0; start < len(plan.changes); {
+		currency := plan.changes[start].key.currency
+		end := start
+		var debits, credits []batchChange
+		var debited, credited economy.Money
+		var err error
+		for ; end < len(plan.changes) && plan.changes[end].key.currency == currency; end++ {
+			c := plan.changes[end]
+			if c.delta < 0 {
+				debits = append(debits, batchChange{c.key, -c.delta})
+				debited, err = debited.Add(-c.delta)
+			} else {
+				credits = append(credits, c)
+				credited, err = credited.Add(c.delta)
+			}
+			if err != nil {
+				return batchPlan{}, NewValidationError("amount", "of currency "+currency+" overflows")
+			}
+		}
+		if debited != credited {
+			return batchPlan{}, NewValidationError("legs", fmt.Sprintf("of currency %s do not balance: %d debited, %d credited", currency, debited, credited))
+		}
+		for i, j := 0, 0; i < len(debits) && j < len(credits); {
+			amount := min(debits[i].delta, credits[j].delta)
+			plan.transfers = append(plan.transfers, batchTransfer{currency, debits[i].key.id, credits[j].key.id, amount})
+			debits[i].delta -= amount
+			credits[j].delta -= amount
+			if debits[i].delta == 0 {
+				i++
+			}
+			if credits[j].delta == 0 {
+				j++
+			}
+		}
+		start = end
+	}
+	return plan, nil
+}
+
+// currencies returns the accounts of the batch by currency.
+func (p batchPlan) currencies() map[string][]uuid.UUID {
+	accounts := make(map[string][]uuid.UUID)
+	for _, c := range p.changes {
+		accounts[c.key.currency] = append(accounts[c.key.currency], c.key.id)
+	}
+	return accounts
+}
+
+// check returns the balance after applying the change to balance.
+func (c batchChange) check(balance economy.Money) (economy.Money, error) {
+	if c.delta < 0 && balance < -c.delta {
+		return 0, NewInsufficientBalanceError(c.key.id, c.key.currency, -c.delta, balance)
+	}
+	next, err := balance.Add(c.delta)
+	if err != nil {
+		return 0, NewValidationError("amount", "balance would overflow")
+	}
+	return next, nil
+}
+
+// entries returns a ledger entry per transfer with the balances after it, starting
+// from the balances before the batch.
+func (p batchPlan) entries(before map[balanceKey]economy.Money, actor uuid.UUID, reason string) []economy.Transaction {
+	balances := maps.Clone(before)
+	entries := make([]economy.Transaction, 0, len(p.transfers))
+	for _, t := range p.transfers {
+		from, to := balanceKey{t.from, t.currency}, balanceKey{t.to, t.currency}
+		balances[from] -= t.amount
+		balances[to] += t.amount
+		entries = append(entries, economy.Transaction{
+			Type:        economy.TransactionBatch,
+			Currency:    t.currency,
+			From:        t.from,
+			To:          t.to,
+			Amount:      t.amount,
+			FromBalance: balances[from],
+			ToBalance:   balances[to],
+			Actor:       actor,
+			Reason:      reason,
+		})
+	}
+	return entries
+}
+
+func (d *DBGorm) Batch(ctx context.Context, legs []economy.Leg, actor uuid.UUID, reason string) ([]economy.Transaction, error) {
+	// Basic data integrity checks
+	plan, err := planBatch(legs)
+	if err != nil {
+		return nil, err
+	}
+	if len(reason) > maxReasonLength {
+		return nil, NewValidationError("reason", fmt.Sprintf("must be at most %d bytes", maxReasonLength))
+	}
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Transaction
+	err = d.transaction(ctx, func(tx *gorm.DB) error {
+		entries = nil
+		if err := claimKey(tx, key, OperationBatch); err != nil {
+			return err
+		}
+		// Lock every balance in currency and account order, like Transfer
+		accounts := plan.currencies()
+		for _, currency := range slices.Sorted(maps.Keys(accounts)) {
+			if err := lockBalances(tx, currency, accounts[currency]...); err != nil {
+				return err
+			}
+		}
+		// Check every leg before changing anything
+		before := make(map[balanceKey]economy.Money, len(plan.changes))
+		for _, c := range plan.changes {
+			err := tx.Where("uuid = ?", c.key.id).First(&Account{}).Error
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return NewNotFoundError("account " + c.key.id.String())
+				}
+				return WrapDatabaseError("account query", err)
+			}
+			balance, err := currentBalance(tx, c.key.id, c.key.currency)
+			if err != nil {
+				return err
+			}
+			if _, err := c.check(balance); err != nil {
+				return err
+			}
+			before[c.key] = balance
+		}
+		for _, c := range plan.changes {
+			if err := addBalance(tx, c.key.id, c.key.currency, c.delta); err != nil {
+				return err
+			}
+		}
+		for _, e := range plan.entries(before, actor, reason) {
+			entry := Transaction{
+				Type:           string(e.Type),
+				Currency:       e.Currency,
+				FromUUID:       e.From.String(),
+				ToUUID:         e.To.String(),
+				Amount:         e.Amount,
+				FromBalance:    e.FromBalance,
+				ToBalance:      e.ToBalance,
+				ActorUUID:      uuidString(e.Actor),
+				Reason:         e.Reason,
+				IdempotencyKey: key,
+			}
+			if err := recordTransaction(tx, &entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toEntries(entries), nil
+}
+
+func (d *DBMemory) Batch(ctx context.Context, legs []economy.Leg, actor uuid.UUID, reason string) ([]economy.Transaction, error) {
+	// Basic data integrity checks
+	plan, err := planBatch(legs)
+	if err != nil {
+		return nil, err
+	}
+	if len(reason) > maxReasonLength {
+		return nil, NewValidationError("reason", fmt.Sprintf("must be at most %d bytes", maxReasonLength))
+	}
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "batch"); err != nil {
+		return nil, err
+	}
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.claim(key, OperationBatch); err != nil {
+		return nil, err
+	}
+	before := make(map[balanceKey]economy.Money, len(plan.changes))
+	for _, c := range plan.changes {
+		if _, ok := d.accounts[c.key.id]; !ok {
+			return nil, NewNotFoundError("account " + c.key.id.String())
+		}
+		balance := tx.balance(c.key.id, c.key.currency)
+		next, err := c.check(balance)
+		if err != nil {
+			return nil, err
+		}
+		before[c.key] = balance
+		tx.setBalance(c.key.id, c.key.currency, next)
+	}
+	for _, entry := range plan.entries(before, actor, reason) {
+		tx.record(entry)
+	}
+	return tx.commit(), nil
+}
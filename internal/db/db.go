@@ -8,14 +8,51 @@ import (
 )
 
 type DB interface {
-	// Get balance
-	Balance(ctx context.Context, id uuid.UUID) (float64, error)
+	// Get balance, accounts without a balance in the currency have zero
+	Balance(ctx context.Context, id uuid.UUID, currency string) (economy.Money, error)
+	// Register a new account of the given kind with initial balances per currency
+	Register(ctx context.Context, id uuid.UUID, name string, kind economy.AccountKind, balances map[string]economy.Money) ([]economy.Transaction, error)
 	// Set balance
-	Set(ctx context.Context, id uuid.UUID, name string, amount float64) error
-	// Transfer Balance
-	Transfer(ctx context.Context, fromID, toID uuid.UUID, amount float64) error
-	// Get balance ranking
-	Top(ctx context.Context, page, size int) ([]economy.EconomyEntry, error)
-	// Get uuid by name
+	Set(ctx context.Context, id uuid.UUID, name, currency string, amount economy.Money, actor uuid.UUID) (economy.Transaction, error)
+	// Adjust adds delta to the balance atomically on behalf of actor. A negative delta
+	// fails with an insufficient balance error unless allowNegative is set; the check
+	// and the debit are one conditional update, so Withdraw and other debits that must
+	// not overdraw use Adjust rather than reading the balance first
+	Adjust(ctx context.Context, id uuid.UUID, currency string, delta economy.Money, allowNegative bool, actor uuid.UUID, reason string) (economy.Transaction, error)
+	// Transfer Balance on behalf of actor. The receiver gets amount minus fee, the fee
+	// is credited to feeSink or burned when feeSink is uuid.Nil
+	Transfer(ctx context.Context, fromID, toID uuid.UUID, currency string, amount, fee economy.Money, feeSink, actor uuid.UUID) ([]economy.Transaction, error)
+	// Batch applies debits and credits of several accounts atomically on behalf of actor.
+	// The legs of every currency must balance and no balance may become negative
+	Batch(ctx context.Context, legs []economy.Leg, actor uuid.UUID, reason string) ([]economy.Transaction, error)
+	// Exchange debits amount of one currency and credits received of another atomically
+	Exchange(ctx context.Context, id uuid.UUID, fromCurrency string, amount economy.Money, toCurrency string, received economy.Money) ([]economy.Transaction, error)
+	// Get balance ranking of player accounts
+	Top(ctx context.Context, currency string, page, size int) ([]economy.EconomyEntry, error)
+	// Get uuid of a player account by name
 	GetUUIDByName(ctx context.Context, name string) (uuid.UUID, error)
+	// Get the kind of an account
+	AccountKind(ctx context.Context, id uuid.UUID) (economy.AccountKind, error)
+	// Get transaction history, newest first. uuid.Nil returns every account's history,
+	// a non-nil counterparty limits it to entries between both accounts
+	History(ctx context.Context, id, counterparty uuid.UUID, page, size int) ([]economy.Transaction, error)
+	// Create a bank account owned by a player with initial balances per currency
+	CreateBank(ctx context.Context, id uuid.UUID, name string, owner uuid.UUID, balances map[string]economy.Money) ([]economy.Transaction, error)
+	// Get a bank account with its members by name
+	Bank(ctx context.Context, name string) (economy.Bank, error)
+	// Add a member to a bank or change their role. check, if not nil, is called with
+	// the current members while the bank is locked and aborts the change with its error
+	SetBankMember(ctx context.Context, bankID, memberID uuid.UUID, role economy.BankRole, check func(economy.Bank) error) error
+	// Remove a member from a bank, check is called like in SetBankMember
+	RemoveBankMember(ctx context.Context, bankID, memberID uuid.UUID, check func(economy.Bank) error) error
+	// Transfer between a bank and a member without fees on behalf of the member. The
+	// bank must be the sender or the receiver, check is called like in SetBankMember
+	BankTransfer(ctx context.Context, bankID, fromID, toID uuid.UUID, currency string, amount economy.Money, check func(economy.Bank) error) ([]economy.Transaction, error)
+	// Replay returns the operation and ledger entries of the mutation that used the
+	// idempotency key. Set, Adjust, Transfer, Batch and Exchange claim the key carried
+	// by their context and fail with ErrDuplicateKey when it was used before
+	Replay(ctx context.Context, key string) (string, []economy.Transaction, error)
+	// Audit returns every balance together with the net amount of its ledger entries,
+	// including ledger entries of balances that do not exist
+	Audit(ctx context.Context) ([]economy.BalanceAudit, error)
 }
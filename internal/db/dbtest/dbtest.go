@@ -0,0 +1,1107 @@
+// Package dbtest provides a conformance suite for implementations of db.DB.
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/internal/db"
+)
+
+// Currency is the currency balances are kept in by the suite.
+const Currency = "money"
+
+// otherCurrency is the second currency used by exchange tests.
+const otherCurrency = "gems"
+
+// Run runs the conformance suite against the implementation returned by newDB,
+// which is called once per test and must return an empty database.
+func Run(t *testing.T, newDB func(t *testing.T) db.DB) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, d db.DB)
+	}{
+		{"Balance", testBalance},
+		{"Register", testRegister},
+		{"Set", testSet},
+		{"Adjust", testAdjust},
+		{"Transfer", testTransfer},
+		{"TransferAtomicity", testTransferAtomicity},
+		{"TransferFee", testTransferFee},
+		{"ConcurrentFirstCredit", testConcurrentFirstCredit},
+		{"ConcurrentFirstAdjust", testConcurrentFirstAdjust},
+		{"Exchange", testExchange},
+		{"Top", testTop},
+		{"GetUUIDByName", testGetUUIDByName},
+		{"AccountKind", testAccountKind},
+		{"History", testHistory},
+		{"Bank", testBank},
+		{"Audit", testAudit},
+		{"Idempotency", testIdempotency},
+		{"ActorName", testActorName},
+		{"Batch", testBatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newDB(t))
+		})
+	}
+}
+
+func testBalance(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	id := register(t, d, "alice", 100)
+
+	assertBalance(t, d, id, Currency, 100)
+	// Currencies without a balance are zero
+	assertBalance(t, d, id, otherCurrency, 0)
+
+	_, err := d.Balance(ctx, uuid.New(), Currency)
+	assertErrorIs(t, err, db.ErrNotFound)
+	_, err = d.Balance(ctx, uuid.Nil, Currency)
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.Balance(ctx, id, " ")
+	assertErrorIs(t, err, db.ErrValidation)
+}
+
+func testRegister(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	id := uuid.New()
+	entries, err := d.Register(ctx, id, "alice", economy.AccountPlayer, map[string]economy.Money{Currency: 100, otherCurrency: 5})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Register returned %d entries, want 2", len(entries))
+	}
+	for _, e := range entries {
+		if e.Type != economy.TransactionRegister || e.To != id || e.Amount != e.ToBalance || e.ID == 0 {
+			t.Errorf("unexpected register entry %+v", e)
+		}
+	}
+	assertBalance(t, d, id, otherCurrency, 5)
+
+	_, err = d.Register(ctx, id, "alice", economy.AccountPlayer, nil)
+	if err == nil {
+		t.Error("registering an existing UUID succeeded")
+	}
+	assertBalance(t, d, id, Currency, 100)
+
+	invalid := []struct {
+		name     string
+		id       uuid.UUID
+		account  string
+		kind     economy.AccountKind
+		balances map[string]economy.Money
+	}{
+		{"nil uuid", uuid.Nil, "bob", economy.AccountPlayer, nil},
+		{"empty name", uuid.New(), " ", economy.AccountPlayer, nil},
+		{"bank kind", uuid.New(), "bob", economy.AccountBank, nil},
+		{"unknown kind", uuid.New(), "bob", "robot", nil},
+		{"empty currency", uuid.New(), "bob", economy.AccountPlayer, map[string]economy.Money{"": 1}},
+		{"negative balance", uuid.New(), "bob", economy.AccountPlayer, map[string]economy.Money{Currency: -1}},
+	}
+	for _, tt := range invalid {
+		_, err := d.Register(ctx, tt.id, tt.account, tt.kind, tt.balances)
+		if !errors.Is(err, db.ErrValidation) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, db.ErrValidation)
+		}
+	}
+}
+
+func testSet(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	admin := uuid.New()
+
+	// Set creates missing accounts
+	id := uuid.New()
+	entry, err := d.Set(ctx, id, "alice", Currency, 250, admin)
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if entry.Type != economy.TransactionSet || entry.Amount != 250 || entry.ToBalance != 250 || entry.Actor != admin {
+		t.Errorf("unexpected set entry %+v", entry)
+	}
+	assertBalance(t, d, id, Currency, 250)
+	if got, err := d.GetUUIDByName(ctx, "alice"); err != nil || got != id {
+		t.Errorf("GetUUIDByName after Set = %v, %v, want %v", got, err, id)
+	}
+
+	// and overwrites existing balances, recording the delta and the new name
+	entry, err = d.Set(ctx, id, "alice2", Currency, 100, admin)
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if entry.Amount != -150 || entry.ToBalance != 100 {
+		t.Errorf("unexpected set entry %+v", entry)
+	}
+	assertBalance(t, d, id, Currency, 100)
+	if got, err := d.GetUUIDByName(ctx, "alice2"); err != nil || got != id {
+		t.Errorf("GetUUIDByName after rename = %v, %v, want %v", got, err, id)
+	}
+
+	// Other currencies are kept
+	if _, err := d.Set(ctx, id, "alice2", otherCurrency, 7, admin); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	assertBalance(t, d, id, Currency, 100)
+	assertBalance(t, d, id, otherCurrency, 7)
+
+	_, err = d.Set(ctx, uuid.Nil, "bob", Currency, 1, admin)
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.Set(ctx, uuid.New(), "", Currency, 1, admin)
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.Set(ctx, uuid.New(), "bob", "", 1, admin)
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.Set(ctx, id, "alice2", Currency, -1, admin)
+	assertErrorIs(t, err, db.ErrValidation)
+	assertBalance(t, d, id, Currency, 100)
+}
+
+func testAdjust(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	admin := uuid.New()
+	id := register(t, d, "alice", 100)
+
+	entry, err := d.Adjust(ctx, id, Currency, 50, false, admin, "prize")
+	if err != nil {
+		t.Fatalf("Adjust: %v", err)
+	}
+	if entry.Type != economy.TransactionGive || entry.To != id || entry.Amount != 50 || entry.ToBalance != 150 || entry.Reason != "prize" {
+		t.Errorf("unexpected give entry %+v", entry)
+	}
+	entry, err = d.Adjust(ctx, id, Currency, -30, false, admin, "")
+	if err != nil {
+		t.Fatalf("Adjust: %v", err)
+	}
+	if entry.Type != economy.TransactionTake || entry.From != id || entry.Amount != 30 || entry.FromBalance != 120 {
+		t.Errorf("unexpected take entry %+v", entry)
+	}
+
+	// Negative balances need allowNegative
+	_, err = d.Adjust(ctx, id, Currency, -121, false, admin, "")
+	assertErrorIs(t, err, db.ErrInsufficientBalance)
+	assertBalance(t, d, id, Currency, 120)
+	assertInsufficient(t, err, id, Currency, 121, 120)
+	if _, err := d.Adjust(ctx, id, Currency, -121, true, admin, ""); err != nil {
+		t.Fatalf("Adjust with allowNegative: %v", err)
+	}
+	assertBalance(t, d, id, Currency, -1)
+
+	// Missing balances start from zero
+	_, err = d.Adjust(ctx, id, otherCurrency, -5, false, admin, "")
+	assertInsufficient(t, err, id, otherCurrency, 5, 0)
+	if _, err := d.Adjust(ctx, id, otherCurrency, 5, false, admin, ""); err != nil {
+		t.Fatalf("Adjust of a new currency: %v", err)
+	}
+	assertBalance(t, d, id, otherCurrency, 5)
+
+	// Overflow is rejected
+	_, err = d.Adjust(ctx, id, otherCurrency, math.MaxInt64, false, admin, "")
+	assertErrorIs(t, err, db.ErrValidation)
+	assertBalance(t, d, id, otherCurrency, 5)
+
+	_, err = d.Adjust(ctx, uuid.New(), Currency, 1, false, admin, "")
+	assertErrorIs(t, err, db.ErrNotFound)
+	_, err = d.Adjust(ctx, uuid.Nil, Currency, 1, false, admin, "")
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.Adjust(ctx, id, Currency, 0, false, admin, "")
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.Adjust(ctx, id, Currency, math.MinInt64, true, admin, "")
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.Adjust(ctx, id, Currency, 1, false, admin, strings.Repeat("x", 256))
+	assertErrorIs(t, err, db.ErrValidation)
+}
+
+func testTransfer(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	alice := register(t, d, "alice", 100)
+	bob := register(t, d, "bob", 10)
+
+	entries, err := d.Transfer(ctx, alice, bob, Currency, 40, 0, uuid.Nil, alice)
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Transfer returned %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Type != economy.TransactionTransfer || e.From != alice || e.To != bob || e.Amount != 40 ||
+		e.FromBalance != 60 || e.ToBalance != 50 || e.Actor != alice {
+		t.Errorf("unexpected transfer entry %+v", e)
+	}
+	assertBalance(t, d, alice, Currency, 60)
+	assertBalance(t, d, bob, Currency, 50)
+
+	// The whole balance can be sent
+	if _, err := d.Transfer(ctx, alice, bob, Currency, 60, 0, uuid.Nil, alice); err != nil {
+		t.Fatalf("Transfer of the whole balance: %v", err)
+	}
+	assertBalance(t, d, alice, Currency, 0)
+	assertBalance(t, d, bob, Currency, 110)
+
+	_, err = d.Transfer(ctx, alice, bob, Currency, 1, 0, uuid.Nil, alice)
+	assertErrorIs(t, err, db.ErrInsufficientBalance)
+	assertInsufficient(t, err, alice, Currency, 1, 0)
+
+	invalid := []struct {
+		name     string
+		from, to uuid.UUID
+		currency string
+		amount   economy.Money
+		fee      economy.Money
+	}{
+		{"nil sender", uuid.Nil, bob, Currency, 1, 0},
+		{"nil receiver", bob, uuid.Nil, Currency, 1, 0},
+		{"empty currency", bob, alice, "", 1, 0},
+		{"zero amount", bob, alice, Currency, 0, 0},
+		{"negative amount", bob, alice, Currency, -1, 0},
+		{"negative fee", bob, alice, Currency, 10, -1},
+		{"fee equal to amount", bob, alice, Currency, 10, 10},
+	}
+	for _, tt := range invalid {
+		_, err := d.Transfer(ctx, tt.from, tt.to, tt.currency, tt.amount, tt.fee, uuid.Nil, tt.from)
+		if !errors.Is(err, db.ErrValidation) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, db.ErrValidation)
+		}
+	}
+	assertBalance(t, d, alice, Currency, 0)
+	assertBalance(t, d, bob, Currency, 110)
+}
+
+func testTransferAtomicity(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	alice := register(t, d, "alice", 100)
+	bob := register(t, d, "bob", math.MaxInt64-10)
+	before := historyLen(t, d)
+
+	// Failed transfers change neither balance nor the ledger
+	failures := []struct {
+		name string
+		to   uuid.UUID
+		amt  economy.Money
+		fee  economy.Money
+		sink uuid.UUID
+		want error
+	}{
+		{"insufficient balance", bob, 101, 0, uuid.Nil, db.ErrInsufficientBalance},
+		{"unknown receiver", uuid.New(), 10, 0, uuid.Nil, db.ErrNotFound},
+		{"receiver overflow", bob, 11, 0, uuid.Nil, db.ErrValidation},
+		{"unknown fee sink", bob, 10, 1, uuid.New(), db.ErrNotFound},
+	}
+	for _, tt := range failures {
+		_, err := d.Transfer(ctx, alice, tt.to, Currency, tt.amt, tt.fee, tt.sink, alice)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+		assertBalance(t, d, alice, Currency, 100)
+		assertBalance(t, d, bob, Currency, math.MaxInt64-10)
+	}
+	_, err := d.Transfer(ctx, uuid.New(), alice, Currency, 1, 0, uuid.Nil, alice)
+	assertErrorIs(t, err, db.ErrNotFound)
+	if after := historyLen(t, d); after != before {
+		t.Errorf("failed transfers wrote %d ledger entries", after-before)
+	}
+}
+
+func testTransferFee(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	alice := register(t, d, "alice", 100)
+	bob := register(t, d, "bob", 0)
+	sink := uuid.New()
+	if _, err := d.Register(ctx, sink, "treasury", economy.AccountSystem, map[string]economy.Money{Currency: 0}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// The fee is credited to the sink
+	entries, err := d.Transfer(ctx, alice, bob, Currency, 50, 5, sink, alice)
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Transfer returned %d entries, want 2", len(entries))
+	}
+	if e := entries[0]; e.Type != economy.TransactionTransfer || e.Amount != 45 || e.ToBalance != 45 {
+		t.Errorf("unexpected transfer entry %+v", e)
+	}
+	if e := entries[1]; e.Type != economy.TransactionFee || e.From != alice || e.To != sink || e.Amount != 5 ||
+		e.FromBalance != 50 || e.ToBalance != 5 {
+		t.Errorf("unexpected fee entry %+v", e)
+	}
+	assertBalance(t, d, alice, Currency, 50)
+	assertBalance(t, d, bob, Currency, 45)
+	assertBalance(t, d, sink, Currency, 5)
+
+	// or burned without a sink
+	entries, err = d.Transfer(ctx, alice, bob, Currency, 50, 5, uuid.Nil, alice)
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	if len(entries) != 2 || entries[1].To != uuid.Nil {
+		t.Errorf("unexpected burn entries %+v", entries)
+	}
+	assertBalance(t, d, alice, Currency, 0)
+	assertBalance(t, d, bob, Currency, 90)
+	assertBalance(t, d, sink, Currency, 5)
+
+	// The sender needs the amount including the fee
+	_, err = d.Transfer(ctx, bob, alice, Currency, 91, 1, uuid.Nil, bob)
+	assertInsufficient(t, err, bob, Currency, 91, 90)
+	assertBalance(t, d, bob, Currency, 90)
+}
+
+// testConcurrentFirstCredit transfers concurrently to a receiver and fee sink that
+// have no balance in the currency yet, so every transfer creates the same rows.
+func testConcurrentFirstCredit(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	const senders = 8
+	receiver := register(t, d, "receiver", 0)
+	sink := uuid.New()
+	if _, err := d.Register(ctx, sink, "treasury", economy.AccountSystem, nil); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	ids := make([]uuid.UUID, senders)
+	for i := range ids {
+		ids[i] = uuid.New()
+		if _, err := d.Register(ctx, ids[i], "sender"+string(rune('a'+i)), economy.AccountPlayer, map[string]economy.Money{otherCurrency: 10}); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := d.Transfer(ctx, id, receiver, otherCurrency, 10, 1, sink, id); err != nil {
+				t.Errorf("Transfer: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	assertBalance(t, d, receiver, otherCurrency, senders*9)
+	assertBalance(t, d, sink, otherCurrency, senders)
+	for _, id := range ids {
+		assertBalance(t, d, id, otherCurrency, 0)
+	}
+}
+
+// testConcurrentFirstAdjust adjusts and sets a balance that does not exist yet
+// concurrently, the ledger must still add up to the balance.
+func testConcurrentFirstAdjust(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	const adjusts = 8
+	id := register(t, d, "alice", 0)
+
+	var wg sync.WaitGroup
+	for range adjusts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := d.Adjust(ctx, id, otherCurrency, 5, false, uuid.Nil, ""); err != nil {
+				t.Errorf("Adjust: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	assertBalance(t, d, id, otherCurrency, adjusts*5)
+
+	for range adjusts {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := d.Adjust(ctx, id, otherCurrency, 5, false, uuid.Nil, ""); err != nil {
+				t.Errorf("Adjust: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := d.Set(ctx, id, "alice", otherCurrency, 100, uuid.Nil); err != nil {
+				t.Errorf("Set: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	audit, err := d.Audit(ctx)
+	if err != nil {
+		t.Fatalf("Audit: %v", err)
+	}
+	for _, a := range audit {
+		if a.Account == id && a.Currency == otherCurrency && a.Balance != a.Ledger {
+			t.Errorf("balance %d does not match the ledger %d", a.Balance, a.Ledger)
+		}
+	}
+}
+
+func testExchange(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	id := register(t, d, "alice", 100)
+
+	entries, err := d.Exchange(ctx, id, Currency, 60, otherCurrency, 3)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Currency != Currency || entries[0].FromBalance != 40 ||
+		entries[1].Currency != otherCurrency || entries[1].ToBalance != 3 {
+		t.Errorf("unexpected exchange entries %+v", entries)
+	}
+	assertBalance(t, d, id, Currency, 40)
+	assertBalance(t, d, id, otherCurrency, 3)
+
+	_, err = d.Exchange(ctx, id, Currency, 41, otherCurrency, 1)
+	assertErrorIs(t, err, db.ErrInsufficientBalance)
+	assertInsufficient(t, err, id, Currency, 41, 40)
+	_, err = d.Exchange(ctx, id, Currency, 1, Currency, 1)
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.Exchange(ctx, id, Currency, 1, otherCurrency, 0)
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.Exchange(ctx, uuid.New(), Currency, 1, otherCurrency, 1)
+	assertErrorIs(t, err, db.ErrNotFound)
+	assertBalance(t, d, id, Currency, 40)
+	assertBalance(t, d, id, otherCurrency, 3)
+}
+
+func testTop(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	names := []string{"carol", "alice", "erin", "bob", "dave"}
+	balances := []economy.Money{300, 500, 100, 400, 200}
+	for i, name := range names {
+		register(t, d, name, balances[i])
+	}
+	// System and bank accounts are not ranked
+	if _, err := d.Register(ctx, uuid.New(), "treasury", economy.AccountSystem, map[string]economy.Money{Currency: 1000}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := d.CreateBank(ctx, uuid.New(), "guild", mustUUID(t, d, "alice"), map[string]economy.Money{Currency: 900}); err != nil {
+		t.Fatalf("CreateBank: %v", err)
+	}
+
+	page1, err := d.Top(ctx, Currency, 1, 2)
+	if err != nil {
+		t.Fatalf("Top: %v", err)
+	}
+	page2, err := d.Top(ctx, Currency, 2, 2)
+	if err != nil {
+		t.Fatalf("Top: %v", err)
+	}
+	page3, err := d.Top(ctx, Currency, 3, 2)
+	if err != nil {
+		t.Fatalf("Top: %v", err)
+	}
+	var got []string
+	for _, page := range [][]economy.EconomyEntry{page1, page2, page3} {
+		for _, e := range page {
+			got = append(got, e.Name)
+		}
+	}
+	want := []string{"alice", "bob", "carol", "dave", "erin"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Top order = %v, want %v", got, want)
+	}
+	if len(page1) != 2 || page1[0].Balance != 500 || page1[0].UUID != mustUUID(t, d, "alice") {
+		t.Errorf("unexpected first page %+v", page1)
+	}
+
+	// Pages past the end are empty
+	page4, err := d.Top(ctx, Currency, 4, 2)
+	if err != nil || len(page4) != 0 {
+		t.Errorf("Top past the end = %v, %v, want no entries", page4, err)
+	}
+	// Other currencies are ranked separately
+	empty, err := d.Top(ctx, otherCurrency, 1, 10)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("Top of an unused currency = %v, %v, want no entries", empty, err)
+	}
+
+	_, err = d.Top(ctx, Currency, 0, 10)
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.Top(ctx, Currency, 1, 0)
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.Top(ctx, "", 1, 10)
+	assertErrorIs(t, err, db.ErrValidation)
+}
+
+func testGetUUIDByName(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	id := register(t, d, "alice", 0)
+	if _, err := d.Register(ctx, uuid.New(), "treasury", economy.AccountSystem, nil); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if got := mustUUID(t, d, "alice"); got != id {
+		t.Errorf("GetUUIDByName = %v, want %v", got, id)
+	}
+	_, err := d.GetUUIDByName(ctx, "nobody")
+	assertErrorIs(t, err, db.ErrNotFound)
+	// System accounts are not players
+	_, err = d.GetUUIDByName(ctx, "treasury")
+	assertErrorIs(t, err, db.ErrNotFound)
+	_, err = d.GetUUIDByName(ctx, " ")
+	assertErrorIs(t, err, db.ErrValidation)
+}
+
+func testAccountKind(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	player := register(t, d, "alice", 0)
+	system, bank := uuid.New(), uuid.New()
+	if _, err := d.Register(ctx, system, "treasury", economy.AccountSystem, nil); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := d.CreateBank(ctx, bank, "guild", player, nil); err != nil {
+		t.Fatalf("CreateBank: %v", err)
+	}
+
+	for id, want := range map[uuid.UUID]economy.AccountKind{player: economy.AccountPlayer, system: economy.AccountSystem, bank: economy.AccountBank} {
+		if got, err := d.AccountKind(ctx, id); err != nil || got != want {
+			t.Errorf("AccountKind = %q, %v, want %q", got, err, want)
+		}
+	}
+	_, err := d.AccountKind(ctx, uuid.New())
+	assertErrorIs(t, err, db.ErrNotFound)
+}
+
+func testHistory(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	alice := register(t, d, "alice", 100)
+	bob := register(t, d, "bob", 100)
+	carol := register(t, d, "carol", 100)
+	for _, to := range []uuid.UUID{bob, carol, bob} {
+		if _, err := d.Transfer(ctx, alice, to, Currency, 10, 0, uuid.Nil, alice); err != nil {
+			t.Fatalf("Transfer: %v", err)
+		}
+	}
+
+	// Newest first with display names
+	entries, err := d.History(ctx, alice, uuid.Nil, 1, 2)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(entries) != 2 || entries[0].ToName != "bob" || entries[0].FromName != "alice" ||
+		entries[1].ToName != "carol" || entries[0].ID <= entries[1].ID {
+		t.Errorf("unexpected history page %+v", entries)
+	}
+	// Paging continues with older entries, the register entry of alice is last
+	entries, err = d.History(ctx, alice, uuid.Nil, 2, 2)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(entries) != 2 || entries[0].ToName != "bob" || entries[1].Type != economy.TransactionRegister {
+		t.Errorf("unexpected second history page %+v", entries)
+	}
+
+	// A counterparty limits the history to entries between both accounts
+	entries, err = d.History(ctx, bob, alice, 1, 10)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("History with counterparty returned %d entries, want 2", len(entries))
+	}
+
+	// uuid.Nil returns every account's history
+	entries, err = d.History(ctx, uuid.Nil, uuid.Nil, 1, 100)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(entries) != 6 {
+		t.Errorf("History of every account returned %d entries, want 6", len(entries))
+	}
+
+	_, err = d.History(ctx, uuid.Nil, alice, 1, 10)
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.History(ctx, alice, uuid.Nil, 0, 10)
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.History(ctx, alice, uuid.Nil, 1, 0)
+	assertErrorIs(t, err, db.ErrValidation)
+}
+
+func testBank(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	alice := register(t, d, "alice", 100)
+	bob := register(t, d, "bob", 100)
+	system := uuid.New()
+	if _, err := d.Register(ctx, system, "treasury", economy.AccountSystem, nil); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	bankID := uuid.New()
+	if _, err := d.CreateBank(ctx, bankID, "guild", alice, map[string]economy.Money{Currency: 0}); err != nil {
+		t.Fatalf("CreateBank: %v", err)
+	}
+	_, err := d.CreateBank(ctx, uuid.New(), "guild", bob, nil)
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.CreateBank(ctx, uuid.New(), "other", system, nil)
+	assertErrorIs(t, err, db.ErrNotFound)
+	_, err = d.CreateBank(ctx, uuid.New(), "other", uuid.Nil, nil)
+	assertErrorIs(t, err, db.ErrValidation)
+
+	// Banks hold balances like other accounts
+	if _, err := d.Transfer(ctx, bob, bankID, Currency, 30, 0, uuid.Nil, bob); err != nil {
+		t.Fatalf("Transfer to bank: %v", err)
+	}
+	assertBalance(t, d, bankID, Currency, 30)
+
+	if err := d.SetBankMember(ctx, bankID, bob, economy.BankRoleDeposit, nil); err != nil {
+		t.Fatalf("SetBankMember: %v", err)
+	}
+	if err := d.SetBankMember(ctx, bankID, bob, economy.BankRoleManage, nil); err != nil {
+		t.Fatalf("SetBankMember: %v", err)
+	}
+	assertErrorIs(t, d.SetBankMember(ctx, bankID, system, economy.BankRoleDeposit, nil), db.ErrNotFound)
+	assertErrorIs(t, d.SetBankMember(ctx, alice, bob, economy.BankRoleDeposit, nil), db.ErrNotFound)
+	assertErrorIs(t, d.SetBankMember(ctx, bankID, bob, "admin", nil), db.ErrValidation)
+
+	// The check sees the current members and its error aborts the change
+	errDenied := errors.New("denied")
+	deny := func(bank economy.Bank) error {
+		if bank.UUID != bankID || bank.Role(bob) != economy.BankRoleManage {
+			t.Errorf("check got bank %+v", bank)
+		}
+		return errDenied
+	}
+	assertErrorIs(t, d.SetBankMember(ctx, bankID, bob, economy.BankRoleDeposit, deny), errDenied)
+	assertErrorIs(t, d.RemoveBankMember(ctx, bankID, bob, deny), errDenied)
+	assertErrorIs(t, d.SetBankMember(ctx, alice, bob, economy.BankRoleDeposit, deny), db.ErrNotFound)
+	_, err = d.BankTransfer(ctx, bankID, bankID, bob, Currency, 10, deny)
+	assertErrorIs(t, err, errDenied)
+	assertBalance(t, d, bankID, Currency, 30)
+
+	// Bank transfers are free of fees and attributed to the member
+	entries, err := d.BankTransfer(ctx, bankID, bankID, bob, Currency, 10, nil)
+	if err != nil {
+		t.Fatalf("BankTransfer: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Amount != 10 || entries[0].Actor != bob {
+		t.Errorf("unexpected bank transfer entries %+v", entries)
+	}
+	assertBalance(t, d, bankID, Currency, 20)
+	assertBalance(t, d, bob, Currency, 80)
+	_, err = d.BankTransfer(ctx, bankID, alice, bob, Currency, 1, nil)
+	assertErrorIs(t, err, db.ErrValidation)
+	_, err = d.BankTransfer(ctx, alice, alice, bob, Currency, 1, nil)
+	assertErrorIs(t, err, db.ErrNotFound)
+	_, err = d.BankTransfer(ctx, bankID, bankID, bob, Currency, 21, nil)
+	assertErrorIs(t, err, db.ErrInsufficientBalance)
+
+	bank, err := d.Bank(ctx, "guild")
+	if err != nil {
+		t.Fatalf("Bank: %v", err)
+	}
+	if bank.UUID != bankID || len(bank.Members) != 2 ||
+		bank.Members[0] != (economy.BankMember{UUID: alice, Name: "alice", Role: economy.BankRoleOwner}) ||
+		bank.Members[1] != (economy.BankMember{UUID: bob, Name: "bob", Role: economy.BankRoleManage}) {
+		t.Errorf("unexpected bank %+v", bank)
+	}
+
+	if err := d.RemoveBankMember(ctx, bankID, bob, nil); err != nil {
+		t.Fatalf("RemoveBankMember: %v", err)
+	}
+	assertErrorIs(t, d.RemoveBankMember(ctx, bankID, bob, nil), db.ErrNotFound)
+	// Removed members can join again
+	if err := d.SetBankMember(ctx, bankID, bob, economy.BankRoleWithdraw, nil); err != nil {
+		t.Fatalf("SetBankMember after removal: %v", err)
+	}
+
+	_, err = d.Bank(ctx, "nothing")
+	assertErrorIs(t, err, db.ErrNotFound)
+	// Players are not banks
+	_, err = d.Bank(ctx, "alice")
+	assertErrorIs(t, err, db.ErrNotFound)
+	// Bank names are only unique among banks
+	if _, err := d.CreateBank(ctx, uuid.New(), "alice", alice, nil); err != nil {
+		t.Errorf("CreateBank named after a player: %v", err)
+	}
+
+	// Only one of concurrent banks with the same name is created
+	var created atomic.Int64
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := d.CreateBank(ctx, uuid.New(), "race", bob, nil)
+			switch {
+			case err == nil:
+				created.Add(1)
+			case !errors.Is(err, db.ErrValidation):
+				t.Errorf("CreateBank: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := created.Load(); n != 1 {
+		t.Errorf("%d banks named race were created, want 1", n)
+	}
+}
+
+func testAudit(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	alice := register(t, d, "alice", 100)
+	bob := register(t, d, "bob", 0)
+	sink := uuid.New()
+	if _, err := d.Register(ctx, sink, "treasury", economy.AccountSystem, nil); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	// Every kind of ledger entry
+	if _, err := d.Transfer(ctx, alice, bob, Currency, 50, 5, sink, alice); err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	if _, err := d.Transfer(ctx, bob, alice, Currency, 10, 1, uuid.Nil, bob); err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	if _, err := d.Exchange(ctx, alice, Currency, 20, otherCurrency, 2); err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if _, err := d.Set(ctx, bob, "bob", Currency, 70, uuid.Nil); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, err := d.Adjust(ctx, bob, Currency, 5, false, uuid.Nil, ""); err != nil {
+		t.Fatalf("Adjust: %v", err)
+	}
+	if _, err := d.Adjust(ctx, alice, Currency, -100, true, uuid.Nil, ""); err != nil {
+		t.Fatalf("Adjust: %v", err)
+	}
+
+	audit, err := d.Audit(ctx)
+	if err != nil {
+		t.Fatalf("Audit: %v", err)
+	}
+	want := map[string]economy.Money{
+		"alice/" + Currency:      -61,
+		"alice/" + otherCurrency: 2,
+		"bob/" + Currency:        75,
+		"treasury/" + Currency:   5,
+	}
+	if len(audit) != len(want) {
+		t.Errorf("Audit returned %d entries, want %d: %+v", len(audit), len(want), audit)
+	}
+	for _, a := range audit {
+		key := a.Name + "/" + a.Currency
+		if balance, ok := want[key]; !ok || a.Balance != balance || a.Ledger != balance {
+			t.Errorf("unexpected audit entry %+v, want balance and ledger %d", a, balance)
+		}
+		if a.Name == "treasury" && a.Kind != economy.AccountSystem {
+			t.Errorf("treasury kind = %q, want %q", a.Kind, economy.AccountSystem)
+		}
+	}
+}
+
+func testIdempotency(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	alice := register(t, d, "alice", 100)
+	bob := register(t, d, "bob", 0)
+	keyed := economy.WithIdempotencyKey(ctx, "order-1")
+
+	// Unknown keys are not found
+	_, _, err := d.Replay(ctx, "order-1")
+	assertErrorIs(t, err, db.ErrNotFound)
+
+	// A failed mutation does not claim the key
+	_, err = d.Transfer(keyed, alice, bob, Currency, 500, 0, uuid.Nil, alice)
+	assertErrorIs(t, err, db.ErrInsufficientBalance)
+	_, _, err = d.Replay(ctx, "order-1")
+	assertErrorIs(t, err, db.ErrNotFound)
+
+	// The first mutation claims the key and tags its entries
+	entries, err := d.Transfer(keyed, alice, bob, Currency, 50, 5, uuid.Nil, alice)
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	for _, e := range entries {
+		if e.IdempotencyKey != "order-1" {
+			t.Errorf("entry %+v is not tagged with the key", e)
+		}
+	}
+	op, replayed, err := d.Replay(ctx, "order-1")
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if op != db.OperationTransfer || len(replayed) != len(entries) {
+		t.Fatalf("Replay = %q, %+v, want %q, %+v", op, replayed, db.OperationTransfer, entries)
+	}
+	for i := range entries {
+		if replayed[i].ID != entries[i].ID || replayed[i].Amount != entries[i].Amount {
+			t.Errorf("replayed entry %+v, want %+v", replayed[i], entries[i])
+		}
+	}
+
+	// Repeated keys are rejected by every mutation without applying
+	before := historyLen(t, d)
+	_, err = d.Transfer(keyed, alice, bob, Currency, 50, 5, uuid.Nil, alice)
+	assertErrorIs(t, err, db.ErrDuplicateKey)
+	_, err = d.Set(keyed, alice, "alice", Currency, 0, uuid.Nil)
+	assertErrorIs(t, err, db.ErrDuplicateKey)
+	_, err = d.Adjust(keyed, alice, Currency, 10, false, uuid.Nil, "")
+	assertErrorIs(t, err, db.ErrDuplicateKey)
+	_, err = d.Exchange(keyed, alice, Currency, 10, otherCurrency, 1)
+	assertErrorIs(t, err, db.ErrDuplicateKey)
+	assertBalance(t, d, alice, Currency, 50)
+	assertBalance(t, d, alice, otherCurrency, 0)
+	assertBalance(t, d, bob, Currency, 45)
+	if n := historyLen(t, d); n != before {
+		t.Errorf("history has %d entries after repeated keys, want %d", n, before)
+	}
+
+	// Set and Adjust claim keys too
+	if _, err := d.Set(economy.WithIdempotencyKey(ctx, "set-1"), bob, "bob", Currency, 10, uuid.Nil); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if op, _, err := d.Replay(ctx, "set-1"); err != nil || op != db.OperationSet {
+		t.Errorf("Replay(set-1) = %q, %v, want %q", op, err, db.OperationSet)
+	}
+	if _, err := d.Adjust(economy.WithIdempotencyKey(ctx, "adjust-1"), bob, Currency, 5, false, uuid.Nil, ""); err != nil {
+		t.Fatalf("Adjust: %v", err)
+	}
+	if op, _, err := d.Replay(ctx, "adjust-1"); err != nil || op != db.OperationAdjust {
+		t.Errorf("Replay(adjust-1) = %q, %v, want %q", op, err, db.OperationAdjust)
+	}
+	exchange := economy.WithIdempotencyKey(ctx, "exchange-1")
+	if _, err := d.Exchange(exchange, bob, Currency, 5, otherCurrency, 1); err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if op, replayed, err := d.Replay(ctx, "exchange-1"); err != nil || op != db.OperationExchange || len(replayed) != 2 {
+		t.Errorf("Replay(exchange-1) = %q, %+v, %v, want %q with both legs", op, replayed, err, db.OperationExchange)
+	}
+	_, err = d.Exchange(exchange, bob, Currency, 5, otherCurrency, 1)
+	assertErrorIs(t, err, db.ErrDuplicateKey)
+	assertBalance(t, d, bob, Currency, 10)
+	assertBalance(t, d, bob, otherCurrency, 1)
+
+	// Keys are limited in length
+	long := economy.WithIdempotencyKey(ctx, strings.Repeat("k", economy.MaxIdempotencyKeyLength+1))
+	_, err = d.Adjust(long, bob, Currency, 5, false, uuid.Nil, "")
+	assertErrorIs(t, err, db.ErrValidation)
+}
+
+func testActorName(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	alice := register(t, d, "alice", 100)
+	bob := register(t, d, "bob", 0)
+	named := economy.WithActorName(ctx, "discord-bot")
+
+	// Every mutation records the actor name of the context in its entries
+	var entries []economy.Transaction
+	entry, err := d.Set(named, alice, "alice", Currency, 200, uuid.Nil)
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	entries = append(entries, entry)
+	if entry, err = d.Adjust(named, alice, Currency, -10, false, uuid.Nil, ""); err != nil {
+		t.Fatalf("Adjust: %v", err)
+	}
+	entries = append(entries, entry)
+	transfer, err := d.Transfer(named, alice, bob, Currency, 50, 5, uuid.Nil, uuid.Nil)
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	entries = append(entries, transfer...)
+	for _, e := range entries {
+		if e.ActorName != "discord-bot" {
+			t.Errorf("entry %+v does not record the actor name", e)
+		}
+	}
+	history, err := d.History(ctx, bob, uuid.Nil, 1, 10)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	// Registration had no actor name
+	for _, e := range history {
+		want := ""
+		if e.Type == economy.TransactionTransfer {
+			want = "discord-bot"
+		}
+		if e.ActorName != want {
+			t.Errorf("history entry %+v has actor name %q, want %q", e, e.ActorName, want)
+		}
+	}
+
+	// Names are limited in length
+	long := economy.WithActorName(ctx, strings.Repeat("n", economy.MaxActorNameLength+1))
+	_, err = d.Adjust(long, bob, Currency, 5, false, uuid.Nil, "")
+	assertErrorIs(t, err, db.ErrValidation)
+}
+
+func testBatch(t *testing.T, d db.DB) {
+	ctx := context.Background()
+	buyer := register(t, d, "buyer", 100)
+	seller := register(t, d, "seller", 0)
+	middleman := register(t, d, "middleman", 0)
+	treasury := uuid.New()
+	if _, err := d.Register(ctx, treasury, "treasury", economy.AccountSystem, nil); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// The buyer pays the seller while the middleman and the treasury take a cut
+	legs := []economy.Leg{
+		economy.Debit(buyer, Currency, 100),
+		economy.Credit(seller, Currency, 90),
+		economy.Credit(middleman, Currency, 7),
+		economy.Credit(treasury, Currency, 3),
+	}
+	entries, err := d.Batch(ctx, legs, buyer, "trade")
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Batch returned %d entries, want 3: %+v", len(entries), entries)
+	}
+	credited := make(map[uuid.UUID]economy.Money)
+	for _, e := range entries {
+		if e.Type != economy.TransactionBatch || e.From != buyer || e.Actor != buyer || e.Reason != "trade" {
+			t.Errorf("unexpected batch entry %+v", e)
+		}
+		credited[e.To] += e.Amount
+		if e.ToBalance != credited[e.To] {
+			t.Errorf("entry %+v has receiver balance %d, want %d", e, e.ToBalance, credited[e.To])
+		}
+	}
+	if last := entries[len(entries)-1]; last.FromBalance != 0 {
+		t.Errorf("last entry %+v has sender balance %d, want 0", last, last.FromBalance)
+	}
+	assertBalance(t, d, buyer, Currency, 0)
+	assertBalance(t, d, seller, Currency, 90)
+	assertBalance(t, d, middleman, Currency, 7)
+	assertBalance(t, d, treasury, Currency, 3)
+
+	// Legs of the same account are netted, several currencies balance on their own
+	if _, err := d.Adjust(ctx, seller, otherCurrency, 10, false, uuid.Nil, ""); err != nil {
+		t.Fatalf("Adjust: %v", err)
+	}
+	entries, err = d.Batch(ctx, []economy.Leg{
+		economy.Debit(seller, Currency, 50),
+		economy.Credit(seller, Currency, 20),
+		economy.Credit(buyer, Currency, 30),
+		economy.Debit(seller, otherCurrency, 10),
+		economy.Credit(buyer, otherCurrency, 10),
+	}, uuid.Nil, "")
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Batch returned %d entries, want 2: %+v", len(entries), entries)
+	}
+	assertBalance(t, d, seller, Currency, 60)
+	assertBalance(t, d, buyer, Currency, 30)
+	assertBalance(t, d, buyer, otherCurrency, 10)
+
+	// Any failing leg fails the whole batch
+	before := historyLen(t, d)
+	_, err = d.Batch(ctx, []economy.Leg{
+		economy.Debit(seller, Currency, 10),
+		economy.Debit(buyer, Currency, 31),
+		economy.Credit(middleman, Currency, 41),
+	}, uuid.Nil, "")
+	assertErrorIs(t, err, db.ErrInsufficientBalance)
+	assertInsufficient(t, err, buyer, Currency, 31, 30)
+	_, err = d.Batch(ctx, []economy.Leg{
+		economy.Debit(seller, Currency, 10),
+		economy.Credit(uuid.New(), Currency, 10),
+	}, uuid.Nil, "")
+	assertErrorIs(t, err, db.ErrNotFound)
+	assertBalance(t, d, seller, Currency, 60)
+	assertBalance(t, d, buyer, Currency, 30)
+	assertBalance(t, d, middleman, Currency, 7)
+	if n := historyLen(t, d); n != before {
+		t.Errorf("history has %d entries after failed batches, want %d", n, before)
+	}
+
+	invalid := []struct {
+		name string
+		legs []economy.Leg
+	}{
+		{"no legs", nil},
+		{"unbalanced", []economy.Leg{economy.Debit(seller, Currency, 10), economy.Credit(buyer, Currency, 9)}},
+		{"unbalanced currency", []economy.Leg{economy.Debit(seller, Currency, 10), economy.Credit(buyer, otherCurrency, 10)}},
+		{"zero amount", []economy.Leg{economy.Debit(seller, Currency, 0), economy.Credit(buyer, Currency, 0)}},
+		{"nil account", []economy.Leg{economy.Debit(uuid.Nil, Currency, 10), economy.Credit(buyer, Currency, 10)}},
+		{"empty currency", []economy.Leg{economy.Debit(seller, "", 10), economy.Credit(buyer, "", 10)}},
+		{"cancelled out", []economy.Leg{economy.Debit(seller, Currency, 10), economy.Credit(seller, Currency, 10)}},
+		{"overflow", []economy.Leg{economy.Credit(seller, Currency, math.MaxInt64), economy.Credit(buyer, Currency, math.MaxInt64)}},
+	}
+	for _, tt := range invalid {
+		_, err := d.Batch(ctx, tt.legs, uuid.Nil, "")
+		if !errors.Is(err, db.ErrValidation) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, db.ErrValidation)
+		}
+	}
+
+	// Batches balance the ledger
+	audit, err := d.Audit(ctx)
+	if err != nil {
+		t.Fatalf("Audit: %v", err)
+	}
+	for _, a := range audit {
+		if a.Balance != a.Ledger {
+			t.Errorf("balance %+v differs from the ledger", a)
+		}
+	}
+}
+
+// register creates a player account with a balance in Currency.
+func register(t *testing.T, d db.DB, name string, balance economy.Money) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	if _, err := d.Register(context.Background(), id, name, economy.AccountPlayer, map[string]economy.Money{Currency: balance}); err != nil {
+		t.Fatalf("Register %s: %v", name, err)
+	}
+	return id
+}
+
+// mustUUID looks up a player account by name.
+func mustUUID(t *testing.T, d db.DB, name string) uuid.UUID {
+	t.Helper()
+	id, err := d.GetUUIDByName(context.Background(), name)
+	if err != nil {
+		t.Fatalf("GetUUIDByName %s: %v", name, err)
+	}
+	return id
+}
+
+// historyLen returns the number of ledger entries of every account.
+func historyLen(t *testing.T, d db.DB) int {
+	t.Helper()
+	entries, err := d.History(context.Background(), uuid.Nil, uuid.Nil, 1, math.MaxInt32)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	return len(entries)
+}
+
+func assertBalance(t *testing.T, d db.DB, id uuid.UUID, currency string, want economy.Money) {
+	t.Helper()
+	got, err := d.Balance(context.Background(), id, currency)
+	if err != nil {
+		t.Fatalf("Balance: %v", err)
+	}
+	if got != want {
+		t.Errorf("Balance(%s) = %d, want %d", currency, got, want)
+	}
+}
+
+func assertErrorIs(t *testing.T, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("got error %v, want %v", err, target)
+	}
+}
+
+// assertInsufficient checks the amounts carried by an insufficient balance error.
+func assertInsufficient(t *testing.T, err error, account uuid.UUID, currency string, required, available economy.Money) {
+	t.Helper()
+	var e *db.InsufficientBalanceError
+	if !errors.As(err, &e) {
+		t.Errorf("got error %v, want *db.InsufficientBalanceError", err)
+		return
+	}
+	if e.Account != account || e.Currency != currency || e.Required != required || e.Available != available {
+		t.Errorf("insufficient balance of %s in %s required %d, available %d, want %s in %s, %d, %d",
+			e.Account, e.Currency, e.Required, e.Available, account, currency, required, available)
+	}
+}
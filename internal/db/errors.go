@@ -3,6 +3,9 @@ package db
 import (
 	"errors"
 	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
 )
 
 // Sentinel errors for DB layer
@@ -11,6 +14,7 @@ var (
 	ErrDatabase            = errors.New("database error")
 	ErrNotFound            = errors.New("record not found")
 	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrDuplicateKey        = errors.New("idempotency key already used")
 )
 
 // NewValidationError creates a new validation error with detailed information
@@ -28,7 +32,33 @@ func NewNotFoundError(resource string) error {
 	return fmt.Errorf("%w: %s not found", ErrNotFound, resource)
 }
 
-// NewInsufficientBalanceError creates a new insufficient balance error with amount details
-func NewInsufficientBalanceError(required, available float64) error {
-	return fmt.Errorf("%w: required %.2f, available %.2f", ErrInsufficientBalance, required, available)
-}
\ No newline at end of file
+// InsufficientBalanceError carries the details of a failed debit, it matches ErrInsufficientBalance
+type InsufficientBalanceError struct {
+	Account   uuid.UUID
+	Currency  string
+	Required  economy.Money
+	Available economy.Money
+}
+
+func (e *InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("%v: required %d, available %d minor units", ErrInsufficientBalance, e.Required, e.Available)
+}
+
+func (e *InsufficientBalanceError) Unwrap() error {
+	return ErrInsufficientBalance
+}
+
+// NewInsufficientBalanceError creates a new insufficient balance error of the account with amount details
+func NewInsufficientBalanceError(id uuid.UUID, currency string, required, available economy.Money) error {
+	return &InsufficientBalanceError{Account: id, Currency: currency, Required: required, Available: available}
+}
+
+// NewDuplicateKeyError creates a new error for an idempotency key used by an earlier mutation
+func NewDuplicateKeyError(key string) error {
+	return fmt.Errorf("%w: %q", ErrDuplicateKey, key)
+}
+
+// WrapDatabaseError creates a new database error keeping the driver error in the chain
+func WrapDatabaseError(operation string, err error) error {
+	return fmt.Errorf("%w: %s failed: %w", ErrDatabase, operation, err)
+}
@@ -1,6 +1,8 @@
 package db
 
 import (
+	"strings"
+
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
@@ -14,7 +16,7 @@ func NewDB(dbType string, dsn string) (*gorm.DB, error) {
 
 	switch dbType {
 	case "sqlite":
-		dialector = sqlite.Dialector{DriverName: "sqlite", DSN: dsn}
+		dialector = sqlite.Dialector{DriverName: "sqlite", DSN: sqliteDSN(dsn)}
 	case "mysql":
 		dialector = mysql.Open(dsn)
 	case "postgres":
@@ -27,3 +29,24 @@ func NewDB(dbType string, dsn string) (*gorm.DB, error) {
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
 }
+
+// sqliteDSN makes transactions take the write lock when they begin and wait for
+// other processes holding it, unless the DSN sets these options itself. A deferred
+// transaction upgrading its read lock fails with SQLITE_BUSY without waiting.
+func sqliteDSN(dsn string) string {
+	var opts []string
+	if !strings.Contains(dsn, "_txlock=") {
+		opts = append(opts, "_txlock=immediate")
+	}
+	if !strings.Contains(dsn, "busy_timeout") {
+		opts = append(opts, "_pragma=busy_timeout(5000)")
+	}
+	if len(opts) == 0 {
+		return dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + strings.Join(opts, "&")
+}
@@ -3,8 +3,10 @@ package db
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"math"
+	"slices"
 	"strings"
 
 	"github.com/google/uuid"
@@ -14,11 +16,16 @@ import (
 	_ "modernc.org/sqlite"
 )
 
+// maxReasonLength is the size of the reason column of the ledger.
+const maxReasonLength = 255
+
 type DBGorm struct {
 	db *gorm.DB
 }
 
-func NewDBGorm(dbType, dsn string) (*DBGorm, func(), error) {
+// NewDBGorm opens the database and migrates the schema. Balances written by older
+// single-currency versions are migrated into the legacy currency.
+func NewDBGorm(dbType, dsn string, legacy economy.Currency) (*DBGorm, func(), error) {
 	db, err := NewDB(dbType, dsn)
 	if err != nil {
 		slog.Error("failed to open database", "error", err)
@@ -31,6 +38,13 @@ func NewDBGorm(dbType, dsn string) (*DBGorm, func(), error) {
 		return nil, nil, err
 	}
 
+	// SQLite allows one writer at a time, concurrent transactions of the same process
+	// would only fail with SQLITE_BUSY. One connection also keeps in-memory databases
+	// from being opened once per connection.
+	if dbType == "sqlite" {
+		sqlDB.SetMaxOpenConns(1)
+	}
+
 	if err := sqlDB.Ping(); err != nil {
 		slog.Error("database ping failed", "error", err)
 		return nil, nil, err
@@ -46,27 +60,34 @@ func NewDBGorm(dbType, dsn string) (*DBGorm, func(), error) {
 	}
 
 	// Migrate the schema
-	if err := migrateSchema(db); err != nil {
+	if err := migrateSchema(db, legacy); err != nil {
 		return nil, nil, err
 	}
 
 	return &DBGorm{db}, cleanup, nil
 }
 
-// MigrateSchema migrates the database schema for the Account model.
-func migrateSchema(db *gorm.DB) error {
-	if err := db.AutoMigrate(&Account{}); err != nil {
+// MigrateSchema migrates the database schema for the Account, Balance, Transaction, BankMember and IdempotencyKey models.
+func migrateSchema(db *gorm.DB, legacy economy.Currency) error {
+	if err := migrateLegacySchema(db, legacy); err != nil {
+		slog.Error("failed to migrate legacy schema", "error", err)
+		return err
+	}
+	if err := db.AutoMigrate(&Account{}, &Balance{}, &Transaction{}, &BankMember{}, &IdempotencyKey{}); err != nil {
 		slog.Error("failed to migrate schema", "error", err)
 		return err
 	}
 	return nil
 }
 
-func (d *DBGorm) Balance(ctx context.Context, id uuid.UUID) (float64, error) {
+func (d *DBGorm) Balance(ctx context.Context, id uuid.UUID, currency string) (economy.Money, error) {
 	// Basic data integrity checks
 	if id == uuid.Nil {
 		return 0, NewValidationError("uuid", "cannot be nil")
 	}
+	if strings.TrimSpace(currency) == "" {
+		return 0, NewValidationError("currency", "cannot be empty")
+	}
 
 	var account Account
 	err := d.db.WithContext(ctx).Where("uuid = ?", id).First(&account).Error
@@ -74,9 +95,9 @@ func (d *DBGorm) Balance(ctx context.Context, id uuid.UUID) (float64, error) {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return 0, NewNotFoundError("player")
 		}
-		return 0, NewDatabaseError("balance query", err.Error())
+		return 0, WrapDatabaseError("balance query", err)
 	}
-	return account.Balance, nil
+	return currentBalance(d.db.WithContext(ctx), id, currency)
 }
 
 func (d *DBGorm) GetUUIDByName(ctx context.Context, name string) (uuid.UUID, error) {
@@ -85,52 +106,239 @@ func (d *DBGorm) GetUUIDByName(ctx context.Context, name string) (uuid.UUID, err
 		return uuid.Nil, NewValidationError("name", "cannot be empty")
 	}
 
-	var uStr string
-	err := d.db.WithContext(ctx).Model(&Account{}).Select("uuid").Where("name = ?", name).Scan(&uStr).Error
+	// First reports a missing row, unlike Scan which leaves the result empty
+	var account Account
+	err := d.db.WithContext(ctx).Select("uuid").
+		Where("name = ? AND kind = ?", name, economy.AccountPlayer).First(&account).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return uuid.Nil, NewNotFoundError("player")
 		}
-		return uuid.Nil, NewDatabaseError("uuid query", err.Error())
+		return uuid.Nil, WrapDatabaseError("uuid query", err)
 	}
 	// convert string to uuid
-	uId, err := uuid.Parse(uStr)
+	uId, err := uuid.Parse(account.UUID)
 	if err != nil {
-		return uuid.Nil, NewDatabaseError("uuid parse", err.Error())
+		return uuid.Nil, WrapDatabaseError("uuid parse", err)
 	}
 	return uId, nil
 }
 
-func (d *DBGorm) Set(ctx context.Context, id uuid.UUID, name string, balance float64) error {
+func (d *DBGorm) AccountKind(ctx context.Context, id uuid.UUID) (economy.AccountKind, error) {
+	var account Account
+	err := d.db.WithContext(ctx).Select("kind").Where("uuid = ?", id.String()).First(&account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", NewNotFoundError("account")
+		}
+		return "", WrapDatabaseError("account query", err)
+	}
+	return economy.AccountKind(account.Kind), nil
+}
+
+func (d *DBGorm) Register(ctx context.Context, id uuid.UUID, name string, kind economy.AccountKind, balances map[string]economy.Money) ([]economy.Transaction, error) {
 	// Basic data integrity checks
 	if id == uuid.Nil {
-		return NewValidationError("uuid", "cannot be nil")
+		return nil, NewValidationError("uuid", "cannot be nil")
 	}
 	if strings.TrimSpace(name) == "" {
-		return NewValidationError("name", "cannot be empty")
+		return nil, NewValidationError("name", "cannot be empty")
 	}
-	if math.IsNaN(balance) || math.IsInf(balance, 0) {
-		return NewValidationError("balance", "must be a valid number")
+	if kind != economy.AccountPlayer && kind != economy.AccountSystem {
+		return nil, NewValidationError("kind", "unknown account kind")
+	}
+	for currency, balance := range balances {
+		if strings.TrimSpace(currency) == "" {
+			return nil, NewValidationError("currency", "cannot be empty")
+		}
+		if balance < 0 {
+			return nil, NewValidationError("balance", "cannot be negative")
+		}
 	}
 
-	result := d.db.WithContext(ctx).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "uuid"}},
-		DoUpdates: clause.AssignmentColumns([]string{"balance", "name"}),
-	}).Create(&Account{
-		UUID:    id.String(),
-		Name:    name,
-		Balance: balance,
+	var entries []Transaction
+	err := d.transaction(ctx, func(tx *gorm.DB) error {
+		var err error
+		entries, err = createAccount(tx, id, name, kind, balances, id)
+		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+	return toEntries(entries), nil
+}
 
-	if result.Error != nil {
-		return NewDatabaseError("balance update", result.Error.Error())
+func (d *DBGorm) Set(ctx context.Context, id uuid.UUID, name, currency string, balance economy.Money, actor uuid.UUID) (economy.Transaction, error) {
+	// Basic data integrity checks
+	if id == uuid.Nil {
+		return economy.Transaction{}, NewValidationError("uuid", "cannot be nil")
+	}
+	if strings.TrimSpace(name) == "" {
+		return economy.Transaction{}, NewValidationError("name", "cannot be empty")
+	}
+	if strings.TrimSpace(currency) == "" {
+		return economy.Transaction{}, NewValidationError("currency", "cannot be empty")
+	}
+	if balance < 0 {
+		return economy.Transaction{}, NewValidationError("balance", "cannot be negative")
+	}
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		return economy.Transaction{}, err
 	}
 
-	return nil
+	var entry Transaction
+	err = d.transaction(ctx, func(tx *gorm.DB) error {
+		if err := claimKey(tx, key, OperationSet); err != nil {
+			return err
+		}
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "uuid"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name"}),
+		}).Create(&Account{
+			UUID: id.String(),
+			Name: name,
+			Kind: string(economy.AccountPlayer),
+		})
+		if result.Error != nil {
+			return WrapDatabaseError("account update", result.Error)
+		}
+		// Lock the balance so concurrent changes cannot commit between reading and writing it
+		if err := lockBalances(tx, currency, id); err != nil {
+			return err
+		}
+		previous, err := currentBalance(tx, id, currency)
+		if err != nil {
+			return err
+		}
+		result = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "account_uuid"}, {Name: "currency"}},
+			DoUpdates: clause.AssignmentColumns([]string{"amount", "updated_at"}),
+		}).Create(&Balance{
+			AccountUUID: id.String(),
+			Currency:    currency,
+			Amount:      balance,
+		})
+		if result.Error != nil {
+			return WrapDatabaseError("balance update", result.Error)
+		}
+		// Record ledger entry, amount holds the delta
+		entry = Transaction{
+			Type:           string(economy.TransactionSet),
+			Currency:       currency,
+			ToUUID:         id.String(),
+			Amount:         balance - previous,
+			ToBalance:      balance,
+			ActorUUID:      uuidString(actor),
+			IdempotencyKey: key,
+		}
+		return recordTransaction(tx, &entry)
+	})
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+	return entry.toEntry(), nil
+}
+
+func (d *DBGorm) Adjust(ctx context.Context, id uuid.UUID, currency string, delta economy.Money, allowNegative bool, actor uuid.UUID, reason string) (economy.Transaction, error) {
+	// Basic data integrity checks
+	if id == uuid.Nil {
+		return economy.Transaction{}, NewValidationError("uuid", "cannot be nil")
+	}
+	if strings.TrimSpace(currency) == "" {
+		return economy.Transaction{}, NewValidationError("currency", "cannot be empty")
+	}
+	if delta == 0 || delta == math.MinInt64 {
+		return economy.Transaction{}, NewValidationError("delta", "must be a non-zero amount")
+	}
+	if len(reason) > maxReasonLength {
+		return economy.Transaction{}, NewValidationError("reason", fmt.Sprintf("must be at most %d bytes", maxReasonLength))
+	}
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+
+	var entry Transaction
+	err = d.transaction(ctx, func(tx *gorm.DB) error {
+		if err := claimKey(tx, key, OperationAdjust); err != nil {
+			return err
+		}
+		// Check account exists
+		err := tx.Where("uuid = ?", id).First(&Account{}).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return NewNotFoundError("player")
+			}
+			return WrapDatabaseError("player query", err)
+		}
+		// Lock the balance, creating it at zero, so concurrent first credits do not collide
+		if err := lockBalances(tx, currency, id); err != nil {
+			return err
+		}
+		// Apply the delta in SQL, the condition guards against overflow and negative balances
+		query := tx.Model(&Balance{}).Where("account_uuid = ? AND currency = ?", id.String(), currency)
+		switch {
+		case delta > 0:
+			query = query.Where("amount <= ?", math.MaxInt64-int64(delta))
+		case !allowNegative:
+			query = query.Where("amount >= ?", -int64(delta))
+		default:
+			query = query.Where("amount >= ?", math.MinInt64-int64(delta))
+		}
+		result := query.Update("amount", gorm.Expr("amount + ?", delta))
+		if result.Error != nil {
+			return WrapDatabaseError("balance update", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			current, err := currentBalance(tx, id, currency)
+			if err != nil {
+				return err
+			}
+			if delta > 0 {
+				return NewValidationError("amount", "balance would overflow")
+			}
+			return NewInsufficientBalanceError(id, currency, -delta, current)
+		}
+		balance, err := currentBalance(tx, id, currency)
+		if err != nil {
+			return err
+		}
+		// Record ledger entry, money enters as give and leaves as take
+		entry = Transaction{
+			Type:      string(economy.TransactionGive),
+			Currency:  currency,
+			ToUUID:    id.String(),
+			Amount:    delta,
+			ToBalance: balance,
+			ActorUUID: uuidString(actor),
+			Reason:    reason,
+		}
+		if delta < 0 {
+			entry = Transaction{
+				Type:        string(economy.TransactionTake),
+				Currency:    currency,
+				FromUUID:    id.String(),
+				Amount:      -delta,
+				FromBalance: balance,
+				ActorUUID:   uuidString(actor),
+				Reason:      reason,
+			}
+		}
+		entry.IdempotencyKey = key
+		return recordTransaction(tx, &entry)
+	})
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+	return entry.toEntry(), nil
 }
 
-func (d *DBGorm) Top(ctx context.Context, page int, size int) ([]economy.EconomyEntry, error) {
+func (d *DBGorm) Top(ctx context.Context, currency string, page int, size int) ([]economy.EconomyEntry, error) {
 	// Basic data integrity checks
+	if strings.TrimSpace(currency) == "" {
+		return nil, NewValidationError("currency", "cannot be empty")
+	}
 	if page <= 0 {
 		return nil, NewValidationError("page", "must be greater than 0")
 	}
@@ -140,78 +348,458 @@ func (d *DBGorm) Top(ctx context.Context, page int, size int) ([]economy.Economy
 
 	offset := (page - 1) * size
 
-	// Fetch top accounts from the database
-	var accounts []Account
-	err := d.db.WithContext(ctx).Model(&Account{}).Limit(size).Offset(offset).Order("balance DESC").Find(&accounts).Error
+	// Fetch top balances of the currency from the database
+	var rows []struct {
+		UUID   string
+		Name   string
+		Amount economy.Money
+	}
+	err := d.db.WithContext(ctx).Model(&Balance{}).
+		Select("accounts.uuid, accounts.name, balances.amount").
+		Joins("JOIN accounts ON accounts.uuid = balances.account_uuid AND accounts.deleted_at IS NULL").
+		Where("balances.currency = ? AND accounts.kind = ?", currency, economy.AccountPlayer).
+		Limit(size).Offset(offset).Order("balances.amount DESC").Scan(&rows).Error
 	if err != nil {
-		return nil, NewDatabaseError("top query", err.Error())
+		return nil, WrapDatabaseError("top query", err)
 	}
 
-	// Convert accounts to EconomyEntry
+	// Convert rows to EconomyEntry
 	var entries []economy.EconomyEntry
-	for _, account := range accounts {
-		u, err := uuid.Parse(string(account.UUID))
+	for _, row := range rows {
+		u, err := uuid.Parse(row.UUID)
 		if err != nil {
 			continue // skip broken uuid
 		}
 		entries = append(entries, economy.EconomyEntry{
 			UUID:    u,
-			Name:    account.Name,
-			Balance: account.Balance,
+			Name:    row.Name,
+			Balance: row.Amount,
 		})
 	}
 	return entries, nil
 }
 
-func (d *DBGorm) Transfer(ctx context.Context, fromID uuid.UUID, toID uuid.UUID, amount float64) error {
+func (d *DBGorm) Transfer(ctx context.Context, fromID uuid.UUID, toID uuid.UUID, currency string, amount, fee economy.Money, feeSink, actor uuid.UUID) ([]economy.Transaction, error) {
+	return d.transfer(ctx, fromID, toID, currency, amount, fee, feeSink, actor, nil)
+}
+
+// transfer implements Transfer. authorize, if not nil, runs first in the transaction
+// and aborts the transfer with its error.
+func (d *DBGorm) transfer(ctx context.Context, fromID uuid.UUID, toID uuid.UUID, currency string, amount, fee economy.Money, feeSink, actor uuid.UUID, authorize func(tx *gorm.DB) error) ([]economy.Transaction, error) {
 	// Basic data integrity checks
 	if fromID == uuid.Nil {
-		return NewValidationError("from_uuid", "cannot be nil")
+		return nil, NewValidationError("from_uuid", "cannot be nil")
 	}
 	if toID == uuid.Nil {
-		return NewValidationError("to_uuid", "cannot be nil")
+		return nil, NewValidationError("to_uuid", "cannot be nil")
 	}
-	if math.IsNaN(amount) || math.IsInf(amount, 0) {
-		return NewValidationError("amount", "must be a valid number")
+	if strings.TrimSpace(currency) == "" {
+		return nil, NewValidationError("currency", "cannot be empty")
 	}
 	if amount <= 0 {
-		return NewValidationError("amount", "must be positive")
+		return nil, NewValidationError("amount", "must be positive")
+	}
+	if fee < 0 || fee >= amount {
+		return nil, NewValidationError("fee", "must be at least 0 and below the amount")
 	}
 
-	return d.db.Transaction(func(tx *gorm.DB) error {
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	received := amount - fee
+	var entries []Transaction
+	err = d.transaction(ctx, func(tx *gorm.DB) error {
+		entries = nil
+		if err := claimKey(tx, key, OperationTransfer); err != nil {
+			return err
+		}
+		if authorize != nil {
+			if err := authorize(tx); err != nil {
+				return err
+			}
+		}
+		// Lock every balance involved before reading, so concurrent transfers cannot
+		// both pass the balance check
+		if err := lockBalances(tx, currency, fromID, toID, feeSink); err != nil {
+			return err
+		}
 		// Check sender exists and get balance
-		var fromAccount Account
-		err := tx.Where("uuid = ?", fromID).First(&fromAccount).Error
+		err := tx.Where("uuid = ?", fromID).First(&Account{}).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return NewNotFoundError("sender")
 			}
-			return NewDatabaseError("sender query", err.Error())
+			return WrapDatabaseError("sender query", err)
 		}
-		if fromAccount.Balance < amount {
-			return NewInsufficientBalanceError(amount, fromAccount.Balance)
+		fromBalance, err := currentBalance(tx, fromID, currency)
+		if err != nil {
+			return err
+		}
+		if fromBalance < amount {
+			return NewInsufficientBalanceError(fromID, currency, amount, fromBalance)
 		}
 		// Check receiver exists
-		err = tx.Model(&Account{}).Where("uuid = ?", toID).First(&Account{}).Error
+		err = tx.Where("uuid = ?", toID).First(&Account{}).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return NewNotFoundError("receiver")
 			}
-			return NewDatabaseError("receiver query", err.Error())
+			return WrapDatabaseError("receiver query", err)
+		}
+		toBalance, err := currentBalance(tx, toID, currency)
+		if err != nil {
+			return err
+		}
+		toBalance, err = toBalance.Add(received)
+		if err != nil {
+			return NewValidationError("amount", "receiver balance would overflow")
 		}
 		// Deduct from sender
-		result := tx.Model(&Account{}).Where("uuid = ?", fromID).Update("balance", gorm.Expr("balance - ?", amount))
-		if result.Error != nil {
-			return NewDatabaseError("sender balance update", result.Error.Error())
+		if err := addBalance(tx, fromID, currency, -amount); err != nil {
+			return err
 		}
 		// Add to receiver
-		result = tx.Model(&Account{}).Where("uuid = ?", toID).Update("balance", gorm.Expr("balance + ?", amount))
-		if result.Error != nil {
-			return NewDatabaseError("receiver balance update", result.Error.Error())
+		if err := addBalance(tx, toID, currency, received); err != nil {
+			return err
+		}
+		// Record ledger entry
+		entries = append(entries, Transaction{
+			Type:        string(economy.TransactionTransfer),
+			Currency:    currency,
+			FromUUID:    fromID.String(),
+			ToUUID:      toID.String(),
+			Amount:      received,
+			FromBalance: fromBalance - received,
+			ToBalance:   toBalance,
+			ActorUUID:   uuidString(actor),
+		})
+		if fee > 0 {
+			// Credit the fee to the sink, or burn it
+			entry := Transaction{
+				Type:        string(economy.TransactionFee),
+				Currency:    currency,
+				FromUUID:    fromID.String(),
+				ToUUID:      uuidString(feeSink),
+				Amount:      fee,
+				FromBalance: fromBalance - amount,
+				ActorUUID:   uuidString(actor),
+			}
+			if feeSink != uuid.Nil {
+				err = tx.Where("uuid = ?", feeSink).First(&Account{}).Error
+				if err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						return NewNotFoundError("fee sink")
+					}
+					return WrapDatabaseError("fee sink query", err)
+				}
+				sinkBalance, err := currentBalance(tx, feeSink, currency)
+				if err != nil {
+					return err
+				}
+				if entry.ToBalance, err = sinkBalance.Add(fee); err != nil {
+					return NewValidationError("fee", "sink balance would overflow")
+				}
+				if err := addBalance(tx, feeSink, currency, fee); err != nil {
+					return err
+				}
+			}
+			entries = append(entries, entry)
+		}
+		for i := range entries {
+			entries[i].IdempotencyKey = key
+			if err := recordTransaction(tx, &entries[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toEntries(entries), nil
+}
+
+func (d *DBGorm) Exchange(ctx context.Context, id uuid.UUID, fromCurrency string, amount economy.Money, toCurrency string, received economy.Money) ([]economy.Transaction, error) {
+	// Basic data integrity checks
+	if id == uuid.Nil {
+		return nil, NewValidationError("uuid", "cannot be nil")
+	}
+	if strings.TrimSpace(fromCurrency) == "" || strings.TrimSpace(toCurrency) == "" {
+		return nil, NewValidationError("currency", "cannot be empty")
+	}
+	if fromCurrency == toCurrency {
+		return nil, NewValidationError("currency", "must differ")
+	}
+	if amount <= 0 || received <= 0 {
+		return nil, NewValidationError("amount", "must be positive")
+	}
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Transaction
+	err = d.transaction(ctx, func(tx *gorm.DB) error {
+		entries = nil
+		if err := claimKey(tx, key, OperationExchange); err != nil {
+			return err
+		}
+		// Lock both balances in currency order, like Batch
+		currencies := []string{fromCurrency, toCurrency}
+		slices.Sort(currencies)
+		for _, currency := range currencies {
+			if err := lockBalances(tx, currency, id); err != nil {
+				return err
+			}
+		}
+		// Check account exists and get balances
+		err := tx.Where("uuid = ?", id).First(&Account{}).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return NewNotFoundError("player")
+			}
+			return WrapDatabaseError("player query", err)
+		}
+		fromBalance, err := currentBalance(tx, id, fromCurrency)
+		if err != nil {
+			return err
+		}
+		if fromBalance < amount {
+			return NewInsufficientBalanceError(id, fromCurrency, amount, fromBalance)
+		}
+		toBalance, err := currentBalance(tx, id, toCurrency)
+		if err != nil {
+			return err
+		}
+		toBalance, err = toBalance.Add(received)
+		if err != nil {
+			return NewValidationError("amount", "balance would overflow")
+		}
+		// Debit the paid currency and credit the received one
+		if err := addBalance(tx, id, fromCurrency, -amount); err != nil {
+			return err
+		}
+		if err := addBalance(tx, id, toCurrency, received); err != nil {
+			return err
+		}
+		// Record one ledger entry per leg
+		entries = []Transaction{{
+			Type:        string(economy.TransactionExchange),
+			Currency:    fromCurrency,
+			FromUUID:    id.String(),
+			Amount:      amount,
+			FromBalance: fromBalance - amount,
+			ActorUUID:   id.String(),
+		}, {
+			Type:      string(economy.TransactionExchange),
+			Currency:  toCurrency,
+			ToUUID:    id.String(),
+			Amount:    received,
+			ToBalance: toBalance,
+			ActorUUID: id.String(),
+		}}
+		for i := range entries {
+			entries[i].IdempotencyKey = key
+			if err := recordTransaction(tx, &entries[i]); err != nil {
+				return err
+			}
 		}
-		// Return nil to indicate success
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return toEntries(entries), nil
+}
+
+func (d *DBGorm) History(ctx context.Context, id uuid.UUID, counterparty uuid.UUID, page int, size int) ([]economy.Transaction, error) {
+	// Basic data integrity checks
+	if page <= 0 {
+		return nil, NewValidationError("page", "must be greater than 0")
+	}
+	if size <= 0 {
+		return nil, NewValidationError("size", "must be greater than 0")
+	}
+	if id == uuid.Nil && counterparty != uuid.Nil {
+		return nil, NewValidationError("uuid", "cannot be nil when filtering by counterparty")
+	}
+
+	offset := (page - 1) * size
+
+	query := d.db.WithContext(ctx).Model(&Transaction{})
+	switch {
+	case counterparty != uuid.Nil:
+		query = query.Where("(from_uuid = ? AND to_uuid = ?) OR (from_uuid = ? AND to_uuid = ?)",
+			id.String(), counterparty.String(), counterparty.String(), id.String())
+	case id != uuid.Nil:
+		query = query.Where("from_uuid = ? OR to_uuid = ?", id.String(), id.String())
+	}
+
+	// Fetch newest ledger entries first
+	var transactions []Transaction
+	err := query.Limit(size).Offset(offset).Order("id DESC").Find(&transactions).Error
+	if err != nil {
+		return nil, WrapDatabaseError("history query", err)
+	}
+
+	// Resolve display names of every account involved
+	uuids := make([]string, 0, len(transactions)*2)
+	for _, t := range transactions {
+		uuids = append(uuids, t.FromUUID, t.ToUUID)
+	}
+	var accounts []Account
+	err = d.db.WithContext(ctx).Select("uuid", "name").Where("uuid IN ?", uuids).Find(&accounts).Error
+	if err != nil {
+		return nil, WrapDatabaseError("history name query", err)
+	}
+	names := make(map[string]string, len(accounts))
+	for _, account := range accounts {
+		names[account.UUID] = account.Name
+	}
+
+	entries := make([]economy.Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		entry := t.toEntry()
+		entry.FromName = names[t.FromUUID]
+		entry.ToName = names[t.ToUUID]
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// createAccount creates an account with its initial balances and records a ledger
+// entry per currency attributed to actor.
+func createAccount(tx *gorm.DB, id uuid.UUID, name string, kind economy.AccountKind, balances map[string]economy.Money, actor uuid.UUID) ([]Transaction, error) {
+	account := Account{
+		UUID: id.String(),
+		Name: name,
+		Kind: string(kind),
+	}
+	if kind == economy.AccountBank {
+		account.BankName = &name
+	}
+	err := tx.Create(&account).Error
+	if err != nil {
+		return nil, WrapDatabaseError("account creation", err)
+	}
+	entries := make([]Transaction, 0, len(balances))
+	for currency, balance := range balances {
+		err := tx.Create(&Balance{
+			AccountUUID: id.String(),
+			Currency:    currency,
+			Amount:      balance,
+		}).Error
+		if err != nil {
+			return nil, WrapDatabaseError("balance creation", err)
+		}
+		// Record ledger entry
+		entry := Transaction{
+			Type:      string(economy.TransactionRegister),
+			Currency:  currency,
+			ToUUID:    id.String(),
+			Amount:    balance,
+			ToBalance: balance,
+			ActorUUID: uuidString(actor),
+		}
+		if err := recordTransaction(tx, &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// currentBalance returns the balance of the account in the currency, zero if it has none.
+func currentBalance(tx *gorm.DB, id uuid.UUID, currency string) (economy.Money, error) {
+	var amount economy.Money
+	err := tx.Model(&Balance{}).Select("amount").
+		Where("account_uuid = ? AND currency = ?", id.String(), currency).Scan(&amount).Error
+	if err != nil {
+		return 0, WrapDatabaseError("balance query", err)
+	}
+	return amount, nil
+}
+
+// addBalance adds delta to the balance of the account, creating the balance if missing.
+func addBalance(tx *gorm.DB, id uuid.UUID, currency string, delta economy.Money) error {
+	result := tx.Model(&Balance{}).Where("account_uuid = ? AND currency = ?", id.String(), currency).
+		Update("amount", gorm.Expr("amount + ?", delta))
+	if result.Error != nil {
+		return WrapDatabaseError("balance update", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+	err := tx.Create(&Balance{
+		AccountUUID: id.String(),
+		Currency:    currency,
+		Amount:      delta,
+	}).Error
+	if err != nil {
+		return WrapDatabaseError("balance creation", err)
+	}
+	return nil
+}
+
+// recordTransaction writes a ledger entry inside the given DB transaction, attributed
+// to the actor name of the transaction context.
+func recordTransaction(tx *gorm.DB, entry *Transaction) error {
+	name, err := actorName(tx.Statement.Context)
+	if err != nil {
+		return err
+	}
+	entry.ActorName = name
+	if err := tx.Create(entry).Error; err != nil {
+		return WrapDatabaseError("ledger write", err)
+	}
+	return nil
+}
+
+// toEntry converts a ledger row into its domain representation.
+func (t Transaction) toEntry() economy.Transaction {
+	return economy.Transaction{
+		ID:             t.ID,
+		Type:           economy.TransactionType(t.Type),
+		Currency:       t.Currency,
+		From:           parseUUID(t.FromUUID),
+		To:             parseUUID(t.ToUUID),
+		Amount:         t.Amount,
+		FromBalance:    t.FromBalance,
+		ToBalance:      t.ToBalance,
+		Actor:          parseUUID(t.ActorUUID),
+		ActorName:      t.ActorName,
+		Reason:         t.Reason,
+		IdempotencyKey: t.IdempotencyKey,
+		CreatedAt:      t.CreatedAt,
+	}
+}
+
+// toEntries converts ledger rows into their domain representation.
+func toEntries(transactions []Transaction) []economy.Transaction {
+	entries := make([]economy.Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		entries = append(entries, t.toEntry())
+	}
+	return entries
+}
+
+// uuidString stores uuid.Nil as an empty column.
+func uuidString(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+	return id.String()
+}
+
+// parseUUID returns uuid.Nil for empty or broken columns.
+func parseUUID(s string) uuid.UUID {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil
+	}
+	return id
 }
 
 // Implementation completeness checks
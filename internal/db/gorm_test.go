@@ -0,0 +1,135 @@
+package db_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+	"github.com/skuralll/dfeconomy/internal/db"
+	"github.com/skuralll/dfeconomy/internal/db/dbtest"
+	"gorm.io/gorm"
+)
+
+// DSNs of the servers the suite also runs against, the tests are skipped when unset.
+// MySQL DSNs need parseTime=true, e.g. user:pass@tcp(localhost:3306)/economy_test?parseTime=true.
+const (
+	mysqlDSNEnv    = "DFECONOMY_TEST_MYSQL_DSN"
+	postgresDSNEnv = "DFECONOMY_TEST_POSTGRES_DSN"
+)
+
+func TestGormSQLite(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) db.DB {
+		return openGorm(t, "sqlite", filepath.Join(t.TempDir(), "economy.db"))
+	})
+}
+
+func TestGormMySQL(t *testing.T) {
+	runServer(t, "mysql", mysqlDSNEnv)
+}
+
+func TestGormPostgres(t *testing.T) {
+	runServer(t, "postgres", postgresDSNEnv)
+}
+
+// runServer runs the suite against a database server, emptying its tables before every test.
+func runServer(t *testing.T, dbType, env string) {
+	dsn := os.Getenv(env)
+	if dsn == "" {
+		t.Skipf("%s is not set", env)
+	}
+	dbtest.Run(t, func(t *testing.T) db.DB {
+		d := openGorm(t, dbType, dsn)
+		conn, err := db.NewDB(dbType, dsn)
+		if err != nil {
+			t.Fatalf("NewDB: %v", err)
+		}
+		if sqlDB, err := conn.DB(); err == nil {
+			t.Cleanup(func() { sqlDB.Close() })
+		}
+		all := conn.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped()
+		for _, model := range []any{&db.Transaction{}, &db.Balance{}, &db.BankMember{}, &db.Account{}, &db.IdempotencyKey{}} {
+			if err := all.Delete(model).Error; err != nil {
+				t.Fatalf("empty table: %v", err)
+			}
+		}
+		return d
+	})
+}
+
+// openGorm opens and migrates the database, closing it when the test ends.
+func openGorm(t *testing.T, dbType, dsn string) db.DB {
+	t.Helper()
+	d, cleanup, err := db.NewDBGorm(dbType, dsn, economy.Currency{Name: dbtest.Currency, Decimals: economy.DefaultScale})
+	if err != nil {
+		t.Fatalf("NewDBGorm: %v", err)
+	}
+	t.Cleanup(cleanup)
+	return d
+}
+
+// legacyAccount is the account model of the first release, which stored a single
+// float balance per account.
+type legacyAccount struct {
+	gorm.Model
+	UUID    string  `gorm:"type:char(36);uniqueIndex;not null"`
+	Name    string  `gorm:"type:varchar(16);not null"`
+	Balance float64 `gorm:"type:real;not null;default:0"`
+}
+
+func (legacyAccount) TableName() string { return "accounts" }
+
+func TestLegacyMigration(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "economy.db")
+	conn, err := db.NewDB("sqlite", path)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	alice, bob := uuid.New(), uuid.New()
+	if err := conn.AutoMigrate(&legacyAccount{}); err != nil {
+		t.Fatalf("legacy schema: %v", err)
+	}
+	err = conn.Create([]legacyAccount{
+		{UUID: alice.String(), Name: "alice", Balance: 12.5},
+		{UUID: bob.String(), Name: "bob", Balance: 3.25},
+		{UUID: uuid.NewString(), Name: "carol"},
+	}).Error
+	if err != nil {
+		t.Fatalf("legacy accounts: %v", err)
+	}
+	if sqlDB, err := conn.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	// Migrating twice must not move the balances or record the opening entries again
+	var d db.DB
+	for range 2 {
+		d = openGorm(t, "sqlite", path)
+	}
+	for id, want := range map[uuid.UUID]economy.Money{alice: 1250, bob: 325} {
+		if got, err := d.Balance(ctx, id, dbtest.Currency); err != nil || got != want {
+			t.Errorf("Balance(%s) = %d, %v, want %d", id, got, err, want)
+		}
+	}
+	audits, err := d.Audit(ctx)
+	if err != nil {
+		t.Fatalf("Audit: %v", err)
+	}
+	for _, a := range audits {
+		if a.Balance != a.Ledger {
+			t.Errorf("%s: balance %d, ledger %d", a.Name, a.Balance, a.Ledger)
+		}
+	}
+
+	entries, err := d.History(ctx, bob, uuid.Nil, 1, 10)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Type != economy.TransactionOpening || entries[0].Amount != 325 ||
+		entries[0].ToBalance != 325 || entries[0].Currency != dbtest.Currency {
+		t.Errorf("history of bob = %+v, want one opening entry of 325", entries)
+	}
+}
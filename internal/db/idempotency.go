@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/skuralll/dfeconomy/economy"
+	"gorm.io/gorm"
+	"modernc.org/sqlite"
+)
+
+// Operations recorded with idempotency keys
+const (
+	OperationSet      = "set"
+	OperationAdjust   = "adjust"
+	OperationTransfer = "transfer"
+	OperationExchange = "exchange"
+)
+
+// idempotencyKey returns the validated idempotency key carried by ctx.
+func idempotencyKey(ctx context.Context) (string, error) {
+	key := economy.IdempotencyKey(ctx)
+	if len(key) > economy.MaxIdempotencyKeyLength {
+		return "", NewValidationError("idempotency key", fmt.Sprintf("must be at most %d bytes", economy.MaxIdempotencyKeyLength))
+	}
+	return key, nil
+}
+
+// actorName returns the validated actor name carried by ctx.
+func actorName(ctx context.Context) (string, error) {
+	name := economy.ActorName(ctx)
+	if len(name) > economy.MaxActorNameLength {
+		return "", NewValidationError("actor name", fmt.Sprintf("must be at most %d bytes", economy.MaxActorNameLength))
+	}
+	return name, nil
+}
+
+// claimKey stores the idempotency key for operation inside the transaction, so the
+// mutation commits only if no other mutation used the key. An empty key is ignored.
+func claimKey(tx *gorm.DB, key, operation string) error {
+	if key == "" {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&IdempotencyKey{}).Where("idempotency_key = ?", key).Count(&count).Error; err != nil {
+		return WrapDatabaseError("idempotency key query", err)
+	}
+	if count > 0 {
+		return NewDuplicateKeyError(key)
+	}
+	// A concurrent mutation may claim the key between the query and the insert
+	if err := tx.Create(&IdempotencyKey{Key: key, Operation: operation}).Error; err != nil {
+		if isDuplicate(err) {
+			return NewDuplicateKeyError(key)
+		}
+		return WrapDatabaseError("idempotency key write", err)
+	}
+	return nil
+}
+
+// isDuplicate reports whether the statement violated a unique constraint.
+func isDuplicate(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// unique_violation
+		return pgErr.Code == "23505"
+	}
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		// ER_DUP_ENTRY
+		return myErr.Number == 1062
+	}
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) {
+		// SQLITE_CONSTRAINT_PRIMARYKEY, SQLITE_CONSTRAINT_UNIQUE
+		return liteErr.Code() == 1555 || liteErr.Code() == 2067
+	}
+	return false
+}
+
+func (d *DBGorm) Replay(ctx context.Context, key string) (string, []economy.Transaction, error) {
+	// Basic data integrity checks
+	if key == "" {
+		return "", nil, NewValidationError("idempotency key", "cannot be empty")
+	}
+
+	var claim IdempotencyKey
+	err := d.db.WithContext(ctx).Where("idempotency_key = ?", key).First(&claim).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, NewNotFoundError("idempotency key")
+		}
+		return "", nil, WrapDatabaseError("idempotency key query", err)
+	}
+	var transactions []Transaction
+	err = d.db.WithContext(ctx).Where("idempotency_key = ?", key).Order("id").Find(&transactions).Error
+	if err != nil {
+		return "", nil, WrapDatabaseError("ledger query", err)
+	}
+	return claim.Operation, toEntries(transactions), nil
+}
+
+func (d *DBMemory) Replay(ctx context.Context, key string) (string, []economy.Transaction, error) {
+	// Basic data integrity checks
+	if key == "" {
+		return "", nil, NewValidationError("idempotency key", "cannot be empty")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "idempotency key query"); err != nil {
+		return "", nil, err
+	}
+	claim, ok := d.keys[key]
+	if !ok {
+		return "", nil, NewNotFoundError("idempotency key")
+	}
+	entries := make([]economy.Transaction, 0, len(claim.entries))
+	for _, i := range claim.entries {
+		entries = append(entries, d.ledger[i])
+	}
+	return claim.operation, entries, nil
+}
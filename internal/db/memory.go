@@ -0,0 +1,812 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skuralll/dfeconomy/economy"
+)
+
+// DBMemory keeps accounts, balances and the ledger in memory. It validates input and
+// reports errors like DBGorm, which makes it suitable for tests and demos. Every
+// operation is atomic: changes are staged and only applied if the operation succeeds.
+type DBMemory struct {
+	mu       sync.Mutex
+	accounts map[uuid.UUID]*memAccount
+	balances map[balanceKey]economy.Money
+	ledger   []economy.Transaction
+	members  map[uuid.UUID][]memMember // Bank members by bank in the order they joined
+	keys     map[string]memKey         // Claimed idempotency keys
+	nextSeq  int
+}
+
+// memAccount is an account row.
+type memAccount struct {
+	id   uuid.UUID
+	name string
+	kind economy.AccountKind
+	seq  int // Creation order
+}
+
+// memMember is a bank member row.
+type memMember struct {
+	id   uuid.UUID
+	role economy.BankRole
+}
+
+// memKey is a claimed idempotency key with the ledger indexes of its entries.
+type memKey struct {
+	operation string
+	entries   []int
+}
+
+// balanceKey identifies the balance of an account in one currency.
+type balanceKey struct {
+	id       uuid.UUID
+	currency string
+}
+
+// NewDBMemory returns an empty in-memory database.
+func NewDBMemory() *DBMemory {
+	return &DBMemory{
+		accounts: make(map[uuid.UUID]*memAccount),
+		balances: make(map[balanceKey]economy.Money),
+		members:  make(map[uuid.UUID][]memMember),
+		keys:     make(map[string]memKey),
+	}
+}
+
+func (d *DBMemory) Balance(ctx context.Context, id uuid.UUID, currency string) (economy.Money, error) {
+	// Basic data integrity checks
+	if id == uuid.Nil {
+		return 0, NewValidationError("uuid", "cannot be nil")
+	}
+	if strings.TrimSpace(currency) == "" {
+		return 0, NewValidationError("currency", "cannot be empty")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "balance query"); err != nil {
+		return 0, err
+	}
+	if _, ok := d.accounts[id]; !ok {
+		return 0, NewNotFoundError("player")
+	}
+	return d.balances[balanceKey{id, currency}], nil
+}
+
+func (d *DBMemory) GetUUIDByName(ctx context.Context, name string) (uuid.UUID, error) {
+	// Basic data integrity checks
+	if strings.TrimSpace(name) == "" {
+		return uuid.Nil, NewValidationError("name", "cannot be empty")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "uuid query"); err != nil {
+		return uuid.Nil, err
+	}
+	// Return the oldest player account with the name
+	var found *memAccount
+	for _, a := range d.accounts {
+		if a.name == name && a.kind == economy.AccountPlayer && (found == nil || a.seq < found.seq) {
+			found = a
+		}
+	}
+	if found == nil {
+		return uuid.Nil, NewNotFoundError("player")
+	}
+	return found.id, nil
+}
+
+func (d *DBMemory) AccountKind(ctx context.Context, id uuid.UUID) (economy.AccountKind, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "account query"); err != nil {
+		return "", err
+	}
+	a, ok := d.accounts[id]
+	if !ok {
+		return "", NewNotFoundError("account")
+	}
+	return a.kind, nil
+}
+
+func (d *DBMemory) Register(ctx context.Context, id uuid.UUID, name string, kind economy.AccountKind, balances map[string]economy.Money) ([]economy.Transaction, error) {
+	// Basic data integrity checks
+	if id == uuid.Nil {
+		return nil, NewValidationError("uuid", "cannot be nil")
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, NewValidationError("name", "cannot be empty")
+	}
+	if kind != economy.AccountPlayer && kind != economy.AccountSystem {
+		return nil, NewValidationError("kind", "unknown account kind")
+	}
+	for currency, balance := range balances {
+		if strings.TrimSpace(currency) == "" {
+			return nil, NewValidationError("currency", "cannot be empty")
+		}
+		if balance < 0 {
+			return nil, NewValidationError("balance", "cannot be negative")
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "account creation"); err != nil {
+		return nil, err
+	}
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.createAccount(id, name, kind, balances, id); err != nil {
+		return nil, err
+	}
+	return tx.commit(), nil
+}
+
+func (d *DBMemory) Set(ctx context.Context, id uuid.UUID, name, currency string, balance economy.Money, actor uuid.UUID) (economy.Transaction, error) {
+	// Basic data integrity checks
+	if id == uuid.Nil {
+		return economy.Transaction{}, NewValidationError("uuid", "cannot be nil")
+	}
+	if strings.TrimSpace(name) == "" {
+		return economy.Transaction{}, NewValidationError("name", "cannot be empty")
+	}
+	if strings.TrimSpace(currency) == "" {
+		return economy.Transaction{}, NewValidationError("currency", "cannot be empty")
+	}
+	if balance < 0 {
+		return economy.Transaction{}, NewValidationError("balance", "cannot be negative")
+	}
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "balance update"); err != nil {
+		return economy.Transaction{}, err
+	}
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+	if err := tx.claim(key, OperationSet); err != nil {
+		return economy.Transaction{}, err
+	}
+	// Create the player account or update its name
+	if _, ok := d.accounts[id]; ok {
+		tx.renames[id] = name
+	} else {
+		tx.accounts = append(tx.accounts, memAccount{id: id, name: name, kind: economy.AccountPlayer})
+	}
+	previous := tx.balance(id, currency)
+	tx.setBalance(id, currency, balance)
+	// Record ledger entry, amount holds the delta
+	tx.record(economy.Transaction{
+		Type:      economy.TransactionSet,
+		Currency:  currency,
+		To:        id,
+		Amount:    balance - previous,
+		ToBalance: balance,
+		Actor:     actor,
+	})
+	return tx.commit()[0], nil
+}
+
+func (d *DBMemory) Adjust(ctx context.Context, id uuid.UUID, currency string, delta economy.Money, allowNegative bool, actor uuid.UUID, reason string) (economy.Transaction, error) {
+	// Basic data integrity checks
+	if id == uuid.Nil {
+		return economy.Transaction{}, NewValidationError("uuid", "cannot be nil")
+	}
+	if strings.TrimSpace(currency) == "" {
+		return economy.Transaction{}, NewValidationError("currency", "cannot be empty")
+	}
+	if delta == 0 || delta == math.MinInt64 {
+		return economy.Transaction{}, NewValidationError("delta", "must be a non-zero amount")
+	}
+	if len(reason) > maxReasonLength {
+		return economy.Transaction{}, NewValidationError("reason", fmt.Sprintf("must be at most %d bytes", maxReasonLength))
+	}
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "balance update"); err != nil {
+		return economy.Transaction{}, err
+	}
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return economy.Transaction{}, err
+	}
+	if err := tx.claim(key, OperationAdjust); err != nil {
+		return economy.Transaction{}, err
+	}
+	if _, ok := d.accounts[id]; !ok {
+		return economy.Transaction{}, NewNotFoundError("player")
+	}
+	current := tx.balance(id, currency)
+	switch {
+	case delta > 0 && current > math.MaxInt64-delta:
+		return economy.Transaction{}, NewValidationError("amount", "balance would overflow")
+	case delta < 0 && !allowNegative && current < -delta:
+		return economy.Transaction{}, NewInsufficientBalanceError(id, currency, -delta, current)
+	case delta < 0 && current < math.MinInt64-delta:
+		return economy.Transaction{}, NewInsufficientBalanceError(id, currency, -delta, current)
+	}
+	balance := current + delta
+	tx.setBalance(id, currency, balance)
+	// Record ledger entry, money enters as give and leaves as take
+	if delta > 0 {
+		tx.record(economy.Transaction{
+			Type:      economy.TransactionGive,
+			Currency:  currency,
+			To:        id,
+			Amount:    delta,
+			ToBalance: balance,
+			Actor:     actor,
+			Reason:    reason,
+		})
+	} else {
+		tx.record(economy.Transaction{
+			Type:        economy.TransactionTake,
+			Currency:    currency,
+			From:        id,
+			Amount:      -delta,
+			FromBalance: balance,
+			Actor:       actor,
+			Reason:      reason,
+		})
+	}
+	return tx.commit()[0], nil
+}
+
+func (d *DBMemory) Top(ctx context.Context, currency string, page int, size int) ([]economy.EconomyEntry, error) {
+	// Basic data integrity checks
+	if strings.TrimSpace(currency) == "" {
+		return nil, NewValidationError("currency", "cannot be empty")
+	}
+	if page <= 0 {
+		return nil, NewValidationError("page", "must be greater than 0")
+	}
+	if size <= 0 {
+		return nil, NewValidationError("size", "must be greater than 0")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "top query"); err != nil {
+		return nil, err
+	}
+	// Collect player accounts holding the currency, richest first
+	var ranked []*memAccount
+	for _, a := range d.accounts {
+		if _, ok := d.balances[balanceKey{a.id, currency}]; ok && a.kind == economy.AccountPlayer {
+			ranked = append(ranked, a)
+		}
+	}
+	slices.SortFunc(ranked, func(a, b *memAccount) int {
+		if c := cmp.Compare(d.balances[balanceKey{b.id, currency}], d.balances[balanceKey{a.id, currency}]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	offset := (page - 1) * size
+	if offset >= len(ranked) {
+		return nil, nil
+	}
+	var entries []economy.EconomyEntry
+	for _, a := range ranked[offset:min(offset+size, len(ranked))] {
+		entries = append(entries, economy.EconomyEntry{
+			UUID:    a.id,
+			Name:    a.name,
+			Balance: d.balances[balanceKey{a.id, currency}],
+		})
+	}
+	return entries, nil
+}
+
+func (d *DBMemory) Transfer(ctx context.Context, fromID uuid.UUID, toID uuid.UUID, currency string, amount, fee economy.Money, feeSink, actor uuid.UUID) ([]economy.Transaction, error) {
+	return d.transfer(ctx, fromID, toID, currency, amount, fee, feeSink, actor, nil)
+}
+
+// transfer implements Transfer. authorize, if not nil, runs first while the lock is
+// held and aborts the transfer with its error.
+func (d *DBMemory) transfer(ctx context.Context, fromID uuid.UUID, toID uuid.UUID, currency string, amount, fee economy.Money, feeSink, actor uuid.UUID, authorize func() error) ([]economy.Transaction, error) {
+	// Basic data integrity checks
+	if fromID == uuid.Nil {
+		return nil, NewValidationError("from_uuid", "cannot be nil")
+	}
+	if toID == uuid.Nil {
+		return nil, NewValidationError("to_uuid", "cannot be nil")
+	}
+	if strings.TrimSpace(currency) == "" {
+		return nil, NewValidationError("currency", "cannot be empty")
+	}
+	if amount <= 0 {
+		return nil, NewValidationError("amount", "must be positive")
+	}
+	if fee < 0 || fee >= amount {
+		return nil, NewValidationError("fee", "must be at least 0 and below the amount")
+	}
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "transfer"); err != nil {
+		return nil, err
+	}
+	received := amount - fee
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.claim(key, OperationTransfer); err != nil {
+		return nil, err
+	}
+	if authorize != nil {
+		if err := authorize(); err != nil {
+			return nil, err
+		}
+	}
+	// Check sender exists and has enough balance
+	if _, ok := d.accounts[fromID]; !ok {
+		return nil, NewNotFoundError("sender")
+	}
+	fromBalance := tx.balance(fromID, currency)
+	if fromBalance < amount {
+		return nil, NewInsufficientBalanceError(fromID, currency, amount, fromBalance)
+	}
+	// Check receiver exists
+	if _, ok := d.accounts[toID]; !ok {
+		return nil, NewNotFoundError("receiver")
+	}
+	toBalance, err := tx.balance(toID, currency).Add(received)
+	if err != nil {
+		return nil, NewValidationError("amount", "receiver balance would overflow")
+	}
+	// Move the money
+	tx.setBalance(fromID, currency, tx.balance(fromID, currency)-amount)
+	tx.setBalance(toID, currency, tx.balance(toID, currency)+received)
+	tx.record(economy.Transaction{
+		Type:        economy.TransactionTransfer,
+		Currency:    currency,
+		From:        fromID,
+		To:          toID,
+		Amount:      received,
+		FromBalance: fromBalance - received,
+		ToBalance:   toBalance,
+		Actor:       actor,
+	})
+	if fee > 0 {
+		// Credit the fee to the sink, or burn it
+		entry := economy.Transaction{
+			Type:        economy.TransactionFee,
+			Currency:    currency,
+			From:        fromID,
+			To:          feeSink,
+			Amount:      fee,
+			FromBalance: fromBalance - amount,
+			Actor:       actor,
+		}
+		if feeSink != uuid.Nil {
+			if _, ok := d.accounts[feeSink]; !ok {
+				return nil, NewNotFoundError("fee sink")
+			}
+			if entry.ToBalance, err = tx.balance(feeSink, currency).Add(fee); err != nil {
+				return nil, NewValidationError("fee", "sink balance would overflow")
+			}
+			tx.setBalance(feeSink, currency, entry.ToBalance)
+		}
+		tx.record(entry)
+	}
+	return tx.commit(), nil
+}
+
+func (d *DBMemory) Exchange(ctx context.Context, id uuid.UUID, fromCurrency string, amount economy.Money, toCurrency string, received economy.Money) ([]economy.Transaction, error) {
+	// Basic data integrity checks
+	if id == uuid.Nil {
+		return nil, NewValidationError("uuid", "cannot be nil")
+	}
+	if strings.TrimSpace(fromCurrency) == "" || strings.TrimSpace(toCurrency) == "" {
+		return nil, NewValidationError("currency", "cannot be empty")
+	}
+	if fromCurrency == toCurrency {
+		return nil, NewValidationError("currency", "must differ")
+	}
+	if amount <= 0 || received <= 0 {
+		return nil, NewValidationError("amount", "must be positive")
+	}
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "exchange"); err != nil {
+		return nil, err
+	}
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.claim(key, OperationExchange); err != nil {
+		return nil, err
+	}
+	// Check account exists and get balances
+	if _, ok := d.accounts[id]; !ok {
+		return nil, NewNotFoundError("player")
+	}
+	fromBalance := tx.balance(id, fromCurrency)
+	if fromBalance < amount {
+		return nil, NewInsufficientBalanceError(id, fromCurrency, amount, fromBalance)
+	}
+	toBalance, err := tx.balance(id, toCurrency).Add(received)
+	if err != nil {
+		return nil, NewValidationError("amount", "balance would overflow")
+	}
+	// Debit the paid currency and credit the received one, one ledger entry per leg
+	tx.setBalance(id, fromCurrency, fromBalance-amount)
+	tx.setBalance(id, toCurrency, toBalance)
+	tx.record(economy.Transaction{
+		Type:        economy.TransactionExchange,
+		Currency:    fromCurrency,
+		From:        id,
+		Amount:      amount,
+		FromBalance: fromBalance - amount,
+		Actor:       id,
+	})
+	tx.record(economy.Transaction{
+		Type:      economy.TransactionExchange,
+		Currency:  toCurrency,
+		To:        id,
+		Amount:    received,
+		ToBalance: toBalance,
+		Actor:     id,
+	})
+	return tx.commit(), nil
+}
+
+func (d *DBMemory) History(ctx context.Context, id uuid.UUID, counterparty uuid.UUID, page int, size int) ([]economy.Transaction, error) {
+	// Basic data integrity checks
+	if page <= 0 {
+		return nil, NewValidationError("page", "must be greater than 0")
+	}
+	if size <= 0 {
+		return nil, NewValidationError("size", "must be greater than 0")
+	}
+	if id == uuid.Nil && counterparty != uuid.Nil {
+		return nil, NewValidationError("uuid", "cannot be nil when filtering by counterparty")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "history query"); err != nil {
+		return nil, err
+	}
+	// Walk the ledger newest first
+	offset := (page - 1) * size
+	entries := []economy.Transaction{}
+	for i := len(d.ledger) - 1; i >= 0 && len(entries) < size; i-- {
+		t := d.ledger[i]
+		switch {
+		case counterparty != uuid.Nil:
+			if !(t.From == id && t.To == counterparty) && !(t.From == counterparty && t.To == id) {
+				continue
+			}
+		case id != uuid.Nil:
+			if t.From != id && t.To != id {
+				continue
+			}
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		t.FromName = d.name(t.From)
+		t.ToName = d.name(t.To)
+		entries = append(entries, t)
+	}
+	return entries, nil
+}
+
+func (d *DBMemory) CreateBank(ctx context.Context, id uuid.UUID, name string, owner uuid.UUID, balances map[string]economy.Money) ([]economy.Transaction, error) {
+	// Basic data integrity checks
+	if id == uuid.Nil || owner == uuid.Nil {
+		return nil, NewValidationError("uuid", "cannot be nil")
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, NewValidationError("name", "cannot be empty")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "bank creation"); err != nil {
+		return nil, err
+	}
+	// Check owner is a player and the name is not taken by another bank
+	if err := d.findAccount(owner, economy.AccountPlayer, "owner"); err != nil {
+		return nil, err
+	}
+	for _, a := range d.accounts {
+		if a.name == name && a.kind == economy.AccountBank {
+			return nil, NewValidationError("name", "is already taken")
+		}
+	}
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.createAccount(id, name, economy.AccountBank, balances, owner); err != nil {
+		return nil, err
+	}
+	entries := tx.commit()
+	d.members[id] = []memMember{{owner, economy.BankRoleOwner}}
+	return entries, nil
+}
+
+func (d *DBMemory) Bank(ctx context.Context, name string) (economy.Bank, error) {
+	// Basic data integrity checks
+	if strings.TrimSpace(name) == "" {
+		return economy.Bank{}, NewValidationError("name", "cannot be empty")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "bank query"); err != nil {
+		return economy.Bank{}, err
+	}
+	var account *memAccount
+	for _, a := range d.accounts {
+		if a.name == name && a.kind == economy.AccountBank && (account == nil || a.seq < account.seq) {
+			account = a
+		}
+	}
+	if account == nil {
+		return economy.Bank{}, NewNotFoundError("bank")
+	}
+	return d.bank(account), nil
+}
+
+// bank returns the bank account with its members.
+func (d *DBMemory) bank(account *memAccount) economy.Bank {
+	bank := economy.Bank{UUID: account.id, Name: account.name}
+	for _, m := range d.members[account.id] {
+		bank.Members = append(bank.Members, economy.BankMember{UUID: m.id, Name: d.name(m.id), Role: m.role})
+	}
+	return bank
+}
+
+// checkBank runs the check of a membership change or bank transfer against the
+// current members of the bank, the caller must hold the lock.
+func (d *DBMemory) checkBank(id uuid.UUID, check func(economy.Bank) error) error {
+	account, ok := d.accounts[id]
+	if !ok || account.kind != economy.AccountBank {
+		return NewNotFoundError("bank")
+	}
+	if check == nil {
+		return nil
+	}
+	return check(d.bank(account))
+}
+
+func (d *DBMemory) BankTransfer(ctx context.Context, bankID, fromID, toID uuid.UUID, currency string, amount economy.Money, check func(economy.Bank) error) ([]economy.Transaction, error) {
+	actor, err := bankTransferActor(bankID, fromID, toID)
+	if err != nil {
+		return nil, err
+	}
+	return d.transfer(ctx, fromID, toID, currency, amount, 0, uuid.Nil, actor, func() error {
+		return d.checkBank(bankID, check)
+	})
+}
+
+func (d *DBMemory) SetBankMember(ctx context.Context, bankID, memberID uuid.UUID, role economy.BankRole, check func(economy.Bank) error) error {
+	// Basic data integrity checks
+	if bankID == uuid.Nil || memberID == uuid.Nil {
+		return NewValidationError("uuid", "cannot be nil")
+	}
+	if !role.Valid() {
+		return NewValidationError("role", "is unknown")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "bank member update"); err != nil {
+		return err
+	}
+	if err := d.checkBank(bankID, check); err != nil {
+		return err
+	}
+	if err := d.findAccount(memberID, economy.AccountPlayer, "member"); err != nil {
+		return err
+	}
+	// Change the role in place so the member keeps their position
+	members := d.members[bankID]
+	for i := range members {
+		if members[i].id == memberID {
+			members[i].role = role
+			return nil
+		}
+	}
+	d.members[bankID] = append(members, memMember{memberID, role})
+	return nil
+}
+
+func (d *DBMemory) RemoveBankMember(ctx context.Context, bankID, memberID uuid.UUID, check func(economy.Bank) error) error {
+	// Basic data integrity checks
+	if bankID == uuid.Nil || memberID == uuid.Nil {
+		return NewValidationError("uuid", "cannot be nil")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := contextError(ctx, "bank member removal"); err != nil {
+		return err
+	}
+	if err := d.checkBank(bankID, check); err != nil {
+		return err
+	}
+	members := d.members[bankID]
+	for i := range members {
+		if members[i].id == memberID {
+			d.members[bankID] = slices.Delete(members, i, i+1)
+			return nil
+		}
+	}
+	return NewNotFoundError("member")
+}
+
+// findAccount checks that the account exists and is of the given kind.
+func (d *DBMemory) findAccount(id uuid.UUID, kind economy.AccountKind, resource string) error {
+	if a, ok := d.accounts[id]; !ok || a.kind != kind {
+		return NewNotFoundError(resource)
+	}
+	return nil
+}
+
+// name returns the display name of the account, empty when unknown.
+func (d *DBMemory) name(id uuid.UUID) string {
+	if a, ok := d.accounts[id]; ok {
+		return a.name
+	}
+	return ""
+}
+
+// memTx stages the changes of one operation until they are committed.
+type memTx struct {
+	d        *DBMemory
+	accounts []memAccount
+	renames  map[uuid.UUID]string
+	balances map[balanceKey]economy.Money
+	ledger   []economy.Transaction
+	key      string // Idempotency key claimed for operation, empty if none
+	op       string
+	actor    string // Actor name of the operation context, see economy.WithActorName
+}
+
+// begin starts staging changes of an operation on behalf of the actor name of ctx,
+// the caller must hold the lock.
+func (d *DBMemory) begin(ctx context.Context) (*memTx, error) {
+	actor, err := actorName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &memTx{
+		d:        d,
+		renames:  make(map[uuid.UUID]string),
+		balances: make(map[balanceKey]economy.Money),
+		actor:    actor,
+	}, nil
+}
+
+// claim stages the idempotency key for operation. An empty key is ignored.
+func (tx *memTx) claim(key, operation string) error {
+	if _, ok := tx.d.keys[key]; ok {
+		return NewDuplicateKeyError(key)
+	}
+	tx.key, tx.op = key, operation
+	return nil
+}
+
+// createAccount stages an account with its initial balances and a ledger entry per
+// currency attributed to actor.
+func (tx *memTx) createAccount(id uuid.UUID, name string, kind economy.AccountKind, balances map[string]economy.Money, actor uuid.UUID) error {
+	if _, ok := tx.d.accounts[id]; ok {
+		return NewDatabaseError("account creation", "uuid "+id.String()+" already exists")
+	}
+	tx.accounts = append(tx.accounts, memAccount{id: id, name: name, kind: kind})
+	for currency, balance := range balances {
+		tx.setBalance(id, currency, balance)
+		tx.record(economy.Transaction{
+			Type:      economy.TransactionRegister,
+			Currency:  currency,
+			To:        id,
+			Amount:    balance,
+			ToBalance: balance,
+			Actor:     actor,
+		})
+	}
+	return nil
+}
+
+// balance returns the staged balance of the account, zero if it has none.
+func (tx *memTx) balance(id uuid.UUID, currency string) economy.Money {
+	key := balanceKey{id, currency}
+	if amount, ok := tx.balances[key]; ok {
+		return amount
+	}
+	return tx.d.balances[key]
+}
+
+// setBalance stages a new balance of the account.
+func (tx *memTx) setBalance(id uuid.UUID, currency string, amount economy.Money) {
+	tx.balances[balanceKey{id, currency}] = amount
+}
+
+// record stages a ledger entry.
+func (tx *memTx) record(entry economy.Transaction) {
+	tx.ledger = append(tx.ledger, entry)
+}
+
+// commit applies the staged changes and returns the ledger entries written.
+func (tx *memTx) commit() []economy.Transaction {
+	d := tx.d
+	for _, a := range tx.accounts {
+		d.nextSeq++
+		a.seq = d.nextSeq
+		d.accounts[a.id] = &a
+	}
+	for id, name := range tx.renames {
+		d.accounts[id].name = name
+	}
+	for key, amount := range tx.balances {
+		d.balances[key] = amount
+	}
+	now := time.Now()
+	claim := memKey{operation: tx.op}
+	entries := make([]economy.Transaction, 0, len(tx.ledger))
+	for _, entry := range tx.ledger {
+		entry.ID = uint(len(d.ledger) + 1)
+		entry.IdempotencyKey = tx.key
+		entry.ActorName = tx.actor
+		entry.CreatedAt = now
+		claim.entries = append(claim.entries, len(d.ledger))
+		d.ledger = append(d.ledger, entry)
+		entries = append(entries, entry)
+	}
+	if tx.key != "" {
+		d.keys[tx.key] = claim
+	}
+	return entries
+}
+
+// contextError reports a cancelled or expired context like a failed query.
+func contextError(ctx context.Context, operation string) error {
+	if err := ctx.Err(); err != nil {
+		return WrapDatabaseError(operation, err)
+	}
+	return nil
+}
+
+// Implementation completeness checks
+var _ DB = (*DBMemory)(nil)
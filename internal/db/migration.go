@@ -0,0 +1,66 @@
+package db
+
+import (
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/skuralll/dfeconomy/economy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migrateLegacySchema upgrades databases of the first release, which stored a single
+// float balance per account: the balances move into per-currency balances of the
+// legacy currency as minor units, each with an opening ledger entry. The migration
+// runs in one transaction.
+func migrateLegacySchema(db *gorm.DB, legacy economy.Currency) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&Account{}) || !migrator.HasColumn(&Account{}, "balance") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(&Account{}, &Balance{}, &Transaction{}); err != nil {
+			return err
+		}
+		if err := moveAccountBalances(tx, legacy); err != nil {
+			return err
+		}
+		slog.Info("Migrated legacy economy schema", "currency", legacy.Name, "decimals", legacy.Decimals)
+		return nil
+	})
+}
+
+// moveAccountBalances copies accounts.balance into balances of the legacy currency,
+// records opening ledger entries for them and drops the column.
+func moveAccountBalances(db *gorm.DB, legacy economy.Currency) error {
+	now := time.Now()
+	err := db.Exec("INSERT INTO balances (created_at, updated_at, account_uuid, currency, amount) "+
+		"SELECT ?, ?, uuid, ?, ROUND(balance * ?) FROM accounts WHERE deleted_at IS NULL",
+		now, now, legacy.Name, math.Pow10(legacy.Decimals)).Error
+	if err != nil {
+		return WrapDatabaseError("legacy balance move", err)
+	}
+	// The first release kept no ledger, so the opening entry is the whole balance
+	err = db.Exec("INSERT INTO transactions (created_at, updated_at, type, currency, from_uuid, to_uuid, amount, to_balance) "+
+		"SELECT ?, ?, ?, currency, '', account_uuid, amount, amount FROM balances WHERE currency = ? AND amount <> 0",
+		now, now, string(economy.TransactionOpening), legacy.Name).Error
+	if err != nil {
+		return WrapDatabaseError("legacy opening entries", err)
+	}
+	return dropColumn(db, &Account{}, "balance")
+}
+
+// dropColumn drops a column that is no longer part of the model. Migrator.DropColumn
+// cannot be used as it only knows about columns of the current model on SQLite.
+func dropColumn(db *gorm.DB, model any, column string) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return WrapDatabaseError("legacy column drop", err)
+	}
+	err := db.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: stmt.Table}, clause.Column{Name: column}).Error
+	if err != nil {
+		return WrapDatabaseError("legacy column drop", err)
+	}
+	return nil
+}
@@ -1,13 +1,54 @@
 package db
 
 import (
+	"github.com/skuralll/dfeconomy/economy"
 	"gorm.io/gorm"
 )
 
 // Accounts represents a user account in the database.
 type Account struct {
 	gorm.Model
-	UUID    string  `gorm:"type:char(36);uniqueIndex;not null"`
-	Name    string  `gorm:"type:varchar(16);not null"`
-	Balance float64 `gorm:"type:real;not null;default:0"`
+	UUID     string  `gorm:"type:char(36);uniqueIndex;not null"`
+	Name     string  `gorm:"type:varchar(16);not null"`
+	Kind     string  `gorm:"type:varchar(16);not null;default:'player';index"` // economy.AccountKind
+	BankName *string `gorm:"type:varchar(16);uniqueIndex"`                     // Name of bank accounts, NULL for other kinds so only bank names are unique
+}
+
+// Balance represents the balance of an account in one currency.
+type Balance struct {
+	gorm.Model
+	AccountUUID string        `gorm:"type:char(36);uniqueIndex:idx_balances_account_currency;not null"`
+	Currency    string        `gorm:"type:varchar(16);uniqueIndex:idx_balances_account_currency;index;not null"`
+	Amount      economy.Money `gorm:"type:bigint;not null;default:0"` // Minor units
+}
+
+// Transaction represents a ledger entry recorded for every balance mutation.
+type Transaction struct {
+	gorm.Model
+	Type           string        `gorm:"type:varchar(16);not null;index"`
+	Currency       string        `gorm:"type:varchar(16);not null;default:''"`
+	FromUUID       string        `gorm:"type:char(36);index"` // Empty when money enters the economy
+	ToUUID         string        `gorm:"type:char(36);index"`
+	Amount         economy.Money `gorm:"type:bigint;not null;default:0"`             // Minor units
+	FromBalance    economy.Money `gorm:"type:bigint;not null;default:0"`             // Sender balance after the transaction
+	ToBalance      economy.Money `gorm:"type:bigint;not null;default:0"`             // Receiver balance after the transaction
+	ActorUUID      string        `gorm:"type:char(36)"`                              // Empty for system operations
+	ActorName      string        `gorm:"type:varchar(64);not null;default:''"`       // Client that initiated the mutation, see economy.WithActorName
+	Reason         string        `gorm:"type:varchar(255);not null;default:''"`      // Audit reason of admin adjustments
+	IdempotencyKey string        `gorm:"type:varchar(64);not null;default:'';index"` // Key of the request that wrote the entry
+}
+
+// IdempotencyKey claims a client-supplied key for the mutation that used it first.
+type IdempotencyKey struct {
+	gorm.Model
+	Key       string `gorm:"column:idempotency_key;type:varchar(64);uniqueIndex;not null"`
+	Operation string `gorm:"type:varchar(16);not null"` // One of the Operation constants
+}
+
+// BankMember grants a player a role on a bank account.
+type BankMember struct {
+	gorm.Model
+	BankUUID   string `gorm:"type:char(36);uniqueIndex:idx_bank_members_bank_member;not null"`
+	MemberUUID string `gorm:"type:char(36);uniqueIndex:idx_bank_members_bank_member;index;not null"`
+	Role       string `gorm:"type:varchar(16);not null"` // economy.BankRole
 }
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"modernc.org/sqlite"
+)
+
+const (
+	maxTxAttempts = 8                      // Attempts of a transaction failing with a retryable error
+	baseTxBackoff = 5 * time.Millisecond   // Backoff before the second attempt, doubled after every attempt
+	maxTxBackoff  = 250 * time.Millisecond // Upper bound of the backoff
+)
+
+// transaction runs fn in a DB transaction. Transactions failing because of a
+// serialization failure, deadlock or busy database are retried with exponential
+// backoff, so fn must not keep state between attempts. opts sets the isolation level
+// and access mode, e.g. snapshotTx.
+func (d *DBGorm) transaction(ctx context.Context, fn func(tx *gorm.DB) error, opts ...*sql.TxOptions) error {
+	backoff := baseTxBackoff
+	for attempt := 1; ; attempt++ {
+		err := d.db.WithContext(ctx).Transaction(fn, opts...)
+		if err == nil || attempt == maxTxAttempts || !isRetryable(err) {
+			return err
+		}
+		// Full jitter spreads out transactions that collided
+		timer := time.NewTimer(rand.N(backoff) + 1)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxTxBackoff)
+	}
+}
+
+// snapshotTx runs a read-only transaction seeing a single snapshot of the database,
+// so reads of several tables are consistent with each other. SQLite transactions are
+// serializable regardless of the isolation level.
+var snapshotTx = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+
+// isRetryable reports whether the transaction failed because of concurrent
+// transactions and may succeed when run again.
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// serialization_failure, deadlock_detected
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		// ER_LOCK_DEADLOCK, ER_LOCK_WAIT_TIMEOUT
+		return myErr.Number == 1213 || myErr.Number == 1205
+	}
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) {
+		// SQLITE_BUSY, SQLITE_LOCKED and their extended codes
+		code := liteErr.Code() & 0xff
+		return code == 5 || code == 6
+	}
+	return false
+}
+
+// lockBalances locks the balances of the accounts in the currency until the
+// transaction ends. Rows are locked one at a time in UUID order, so transactions
+// locking the same accounts cannot deadlock. SQLite locks the whole database instead.
+// Missing balances are created at zero first: SELECT FOR UPDATE cannot lock a row
+// that does not exist, and concurrent first credits would otherwise both insert it
+// and fail on the unique index.
+func lockBalances(tx *gorm.DB, currency string, ids ...uuid.UUID) error {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != uuid.Nil {
+			keys = append(keys, id.String())
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range slices.Compact(keys) {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "account_uuid"}, {Name: "currency"}},
+			DoNothing: true,
+		}).Create(&Balance{AccountUUID: key, Currency: currency}).Error
+		if err != nil {
+			return WrapDatabaseError("balance creation", err)
+		}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&Balance{}).Select("id").
+			Where("account_uuid = ? AND currency = ?", key, currency).Find(&[]Balance{}).Error
+		if err != nil {
+			return WrapDatabaseError("balance lock", err)
+		}
+	}
+	return nil
+}